@@ -0,0 +1,200 @@
+package gig
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// bind populates fields of struct pointed to by i from path params and query
+// params, using `param` and `query` struct tags. A tag may be followed by
+// ",required" to reject requests where the value is missing or empty.
+func bind(c Context, i interface{}) error {
+	v := reflect.ValueOf(i)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return ErrInvalidBindTarget
+	}
+
+	return bindStruct(c, v.Elem(), c.QueryParams())
+}
+
+func bindStruct(c Context, v reflect.Value, query url.Values) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		var (
+			sf = t.Field(i)
+			f  = v.Field(i)
+		)
+
+		ptag, hasParam := sf.Tag.Lookup("param")
+		qtag, hasQuery := sf.Tag.Lookup("query")
+
+		if !hasParam && !hasQuery {
+			// Exported fields of embedded structs are settable even if the
+			// embedded type is not
+			if sf.Anonymous && f.Kind() == reflect.Struct {
+				if err := bindStruct(c, f, query); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		if !f.CanSet() {
+			continue
+		}
+
+		var (
+			name     string
+			values   []string
+			required bool
+		)
+
+		if hasParam {
+			name, required = parseBindTag(ptag, sf.Name)
+
+			if p := c.Param(name); p != "" {
+				p, err := url.PathUnescape(p)
+				if err != nil {
+					return NewErrorFrom(ErrBadRequest, fmt.Sprintf("Invalid %s", name))
+				}
+
+				values = []string{p}
+			}
+		}
+
+		if hasQuery && len(values) == 0 {
+			var r bool
+
+			name, r = parseBindTag(qtag, sf.Name)
+			required = required || r
+			values = query[name]
+		}
+
+		if len(values) == 0 || (len(values) == 1 && values[0] == "") {
+			if required {
+				return NewErrorFrom(ErrBadRequest, fmt.Sprintf("Missing %s", name))
+			}
+
+			continue
+		}
+
+		if err := setField(f, values); err != nil {
+			if _, ok := err.(*GeminiError); ok {
+				return err
+			}
+
+			return NewErrorFrom(ErrBadRequest, fmt.Sprintf("Invalid %s", name))
+		}
+	}
+
+	return nil
+}
+
+func parseBindTag(tag, field string) (name string, required bool) {
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+
+	if name == "" {
+		name = strings.ToLower(field)
+	}
+
+	for _, opt := range parts[1:] {
+		if opt == "required" {
+			required = true
+		}
+	}
+
+	return
+}
+
+func setField(f reflect.Value, values []string) error {
+	if f.Kind() == reflect.Slice && f.Type().Elem().Kind() != reflect.Uint8 {
+		s := reflect.MakeSlice(f.Type(), len(values), len(values))
+
+		for i, v := range values {
+			if err := setValue(s.Index(i), v); err != nil {
+				return err
+			}
+		}
+
+		f.Set(s)
+
+		return nil
+	}
+
+	return setValue(f, values[0])
+}
+
+func setValue(f reflect.Value, v string) error {
+	if f.Kind() == reflect.Ptr {
+		p := reflect.New(f.Type().Elem())
+		if err := setValue(p.Elem(), v); err != nil {
+			return err
+		}
+
+		f.Set(p)
+
+		return nil
+	}
+
+	if u, ok := f.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(v))
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(v)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+
+		f.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if f.Type() == durationType {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return err
+			}
+
+			f.SetInt(int64(d))
+
+			return nil
+		}
+
+		i, err := strconv.ParseInt(v, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		f.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(v, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		f.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(v, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		f.SetFloat(n)
+	default:
+		return fmt.Errorf("gig: cannot bind field of kind %s", f.Kind())
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package gig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+type bindPage struct {
+	Page int `query:"page"`
+}
+
+type bindSearch struct {
+	bindPage
+	User    string        `param:"user,required"`
+	Q       string        `query:"q,required"`
+	Tags    []string      `query:"tag"`
+	Limit   *uint8        `query:"limit"`
+	Exact   bool          `query:"exact"`
+	Timeout time.Duration `query:"timeout"`
+	Since   time.Time     `query:"since"`
+	ignored string        `query:"ignored"`
+}
+
+func TestContextQueryParams(t *testing.T) {
+	is := is.New(t)
+
+	g := New()
+	c, _ := g.NewFakeContext("/?a=1&b=x%20y&a=2&c", nil)
+
+	is.Equal([]string{"1", "2"}, c.QueryParams()["a"])
+	is.Equal("1", c.QueryParam("a"))
+	is.Equal("x y", c.QueryParam("b"))
+	is.Equal("", c.QueryParam("c"))
+	is.Equal("", c.QueryParam("missing"))
+}
+
+func TestContextBind(t *testing.T) {
+	is := is.New(t)
+
+	g := New()
+	g.Handle("/users/:user/search", func(c Context) error {
+		var s bindSearch
+		if err := c.Bind(&s); err != nil {
+			return err
+		}
+
+		limit := -1
+		if s.Limit != nil {
+			limit = int(*s.Limit)
+		}
+
+		return c.Text("%s %s %v %d %d %v %s %d %q", s.User, s.Q, s.Tags, limit, s.Page,
+			s.Exact, s.Timeout, s.Since.Year(), s.ignored)
+	})
+
+	is.Equal("20 text/plain\r\njon a b [x y] 5 2 true 1m0s 2020 \"\"",
+		request("/users/jon/search?q=a+b&tag=x&tag=y&limit=5&page=2&exact=1&timeout=1m&since=2020-01-02T00:00:00Z&ignored=1", g))
+	is.Equal("20 text/plain\r\njön a [] -1 0 false 0s 1 \"\"", request("/users/j%C3%B6n/search?q=a", g))
+	is.Equal("59 Missing q\r\n", request("/users/jon/search", g))
+	is.Equal("59 Missing q\r\n", request("/users/jon/search?q=", g))
+	is.Equal("59 Invalid limit\r\n", request("/users/jon/search?q=a&limit=300", g))
+	is.Equal("59 Invalid page\r\n", request("/users/jon/search?q=a&page=x", g))
+	is.Equal("59 Invalid since\r\n", request("/users/jon/search?q=a&since=never", g))
+
+	c, _ := g.NewFakeContext("/", nil)
+	is.Equal(ErrInvalidBindTarget, c.Bind(bindSearch{}))
+}
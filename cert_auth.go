@@ -1,7 +1,11 @@
 package gig
 
 import (
+	"crypto/ecdsa"
+	"crypto/rsa"
 	"crypto/x509"
+	"fmt"
+	"time"
 )
 
 type (
@@ -17,6 +21,27 @@ type (
 
 	// CertAuthValidator defines a function to validate CertAuth credentials.
 	CertAuthValidator func(*x509.Certificate, Context) *GeminiError
+
+	// CertValidityConfig defines the config for CertValidity validator.
+	CertValidityConfig struct {
+		// Skew is tolerated difference between client and server clocks when
+		// checking NotBefore and NotAfter.
+		// Optional. Default value 5 minutes.
+		Skew time.Duration
+
+		// MinRSABits is the minimum size of RSA keys.
+		// Optional. Default value 2048.
+		MinRSABits int
+
+		// MinECDSABits is the minimum size of ECDSA keys.
+		// Optional. Default value 256.
+		MinECDSABits int
+
+		// SignatureAlgorithms lists accepted signature algorithms.
+		// Optional. Default value accepts all but MD2, MD5 and SHA-1 based
+		// algorithms.
+		SignatureAlgorithms []x509.SignatureAlgorithm
+	}
 )
 
 var (
@@ -25,6 +50,22 @@ var (
 		Skipper:   DefaultSkipper,
 		Validator: ValidateHasCertificate,
 	}
+
+	// DefaultCertValidityConfig is the default CertValidity validator config.
+	DefaultCertValidityConfig = CertValidityConfig{
+		Skew:         5 * time.Minute,
+		MinRSABits:   2048,
+		MinECDSABits: 256,
+	}
+
+	weakSignatureAlgorithms = map[x509.SignatureAlgorithm]bool{
+		x509.UnknownSignatureAlgorithm: true,
+		x509.MD2WithRSA:                true,
+		x509.MD5WithRSA:                true,
+		x509.SHA1WithRSA:               true,
+		x509.DSAWithSHA1:               true,
+		x509.ECDSAWithSHA1:             true,
+	}
 )
 
 // ValidateHasCertificate returns ErrClientCertificateRequired if no certificate is sent.
@@ -39,6 +80,77 @@ func ValidateHasCertificate(cert *x509.Certificate, c Context) *GeminiError {
 	return nil
 }
 
+// CertValidity returns a validator that rejects expired or not yet valid
+// certificates, weak keys and weak signature algorithms with
+// ErrCertificateNotValid, explaining the reason. Like ValidateHasCertificate,
+// it requires a certificate and stores subject name in context under "subject".
+//
+// Use it with `CertAuth()`, as client certificates are not verified by TLS.
+func CertValidity(config CertValidityConfig) CertAuthValidator {
+	// Defaults
+	if config.Skew == 0 {
+		config.Skew = DefaultCertValidityConfig.Skew
+	}
+
+	if config.MinRSABits == 0 {
+		config.MinRSABits = DefaultCertValidityConfig.MinRSABits
+	}
+
+	if config.MinECDSABits == 0 {
+		config.MinECDSABits = DefaultCertValidityConfig.MinECDSABits
+	}
+
+	return func(cert *x509.Certificate, c Context) *GeminiError {
+		if err := ValidateHasCertificate(cert, c); err != nil {
+			return err
+		}
+
+		now := time.Now()
+
+		if now.Add(config.Skew).Before(cert.NotBefore) {
+			return NewErrorFrom(ErrCertificateNotValid, "Certificate is not valid yet")
+		}
+
+		if now.Add(-config.Skew).After(cert.NotAfter) {
+			return NewErrorFrom(ErrCertificateNotValid, "Certificate has expired")
+		}
+
+		switch k := cert.PublicKey.(type) {
+		case *rsa.PublicKey:
+			if bits := k.N.BitLen(); bits < config.MinRSABits {
+				return NewErrorFrom(ErrCertificateNotValid,
+					fmt.Sprintf("RSA key is too short, %d bits given, %d required", bits, config.MinRSABits))
+			}
+		case *ecdsa.PublicKey:
+			if bits := k.Params().BitSize; bits < config.MinECDSABits {
+				return NewErrorFrom(ErrCertificateNotValid,
+					fmt.Sprintf("ECDSA key is too short, %d bits given, %d required", bits, config.MinECDSABits))
+			}
+		}
+
+		if !config.allowSignature(cert.SignatureAlgorithm) {
+			return NewErrorFrom(ErrCertificateNotValid,
+				fmt.Sprintf("Signature algorithm %s is not accepted", cert.SignatureAlgorithm))
+		}
+
+		return nil
+	}
+}
+
+func (config *CertValidityConfig) allowSignature(alg x509.SignatureAlgorithm) bool {
+	if len(config.SignatureAlgorithms) == 0 {
+		return !weakSignatureAlgorithms[alg]
+	}
+
+	for _, a := range config.SignatureAlgorithms {
+		if a == alg {
+			return true
+		}
+	}
+
+	return false
+}
+
 // CertAuth returns an CertAuth middleware.
 //
 // For valid credentials it calls the next handler.
@@ -1,10 +1,15 @@
 package gig
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"testing"
+	"time"
 
 	"github.com/matryer/is"
 )
@@ -96,3 +101,77 @@ func TestCertAuth(t *testing.T) {
 		})
 	}
 }
+
+func TestCertValidity(t *testing.T) {
+	is := is.New(t)
+
+	var (
+		g          = New()
+		now        = time.Now()
+		p256, _    = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		p224, _    = ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+		rsa1024, _ = rsa.GenerateKey(rand.Reader, 1024)
+	)
+
+	valid := func() *x509.Certificate {
+		return &x509.Certificate{
+			Subject:            pkix.Name{CommonName: "tester"},
+			NotBefore:          now.Add(-time.Hour),
+			NotAfter:           now.Add(time.Hour),
+			PublicKey:          &p256.PublicKey,
+			SignatureAlgorithm: x509.ECDSAWithSHA256,
+		}
+	}
+
+	h := CertAuth(CertValidity(CertValidityConfig{}))(func(c Context) error {
+		return c.Gemini("%s", c.Get("subject"))
+	})
+
+	testCases := []struct {
+		modify   func(*x509.Certificate)
+		expected string
+	}{
+		{func(*x509.Certificate) {}, "20 text/gemini\r\ntester"},
+		{func(c *x509.Certificate) { c.NotBefore = now.Add(time.Minute) }, "20 text/gemini\r\ntester"},
+		{func(c *x509.Certificate) { c.NotAfter = now.Add(-time.Minute) }, "20 text/gemini\r\ntester"},
+		{func(c *x509.Certificate) { c.NotBefore = now.Add(time.Hour) }, "62 Certificate is not valid yet\r\n"},
+		{func(c *x509.Certificate) { c.NotAfter = now.Add(-time.Hour) }, "62 Certificate has expired\r\n"},
+		{func(c *x509.Certificate) { c.PublicKey = &p224.PublicKey }, "62 ECDSA key is too short, 224 bits given, 256 required\r\n"},
+		{func(c *x509.Certificate) { c.PublicKey = &rsa1024.PublicKey }, "62 RSA key is too short, 1024 bits given, 2048 required\r\n"},
+		{func(c *x509.Certificate) { c.SignatureAlgorithm = x509.SHA1WithRSA }, "62 Signature algorithm SHA1-RSA is not accepted\r\n"},
+	}
+
+	for _, tc := range testCases {
+		cert := valid()
+		tc.modify(cert)
+
+		c, conn := g.NewFakeContext("/", &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}})
+		if err := h(c); err != nil {
+			g.GeminiErrorHandler(err, c)
+		}
+
+		is.Equal(tc.expected, conn.Written)
+	}
+
+	c, _ := g.NewFakeContext("/", nil)
+	is.Equal(ErrClientCertificateRequired, h(c))
+
+	// Custom config
+	h = CertAuth(CertValidity(CertValidityConfig{
+		MinRSABits:          1024,
+		SignatureAlgorithms: []x509.SignatureAlgorithm{x509.SHA256WithRSA},
+	}))(func(c Context) error {
+		return c.Gemini("ok")
+	})
+
+	cert := valid()
+	cert.PublicKey = &rsa1024.PublicKey
+	cert.SignatureAlgorithm = x509.SHA256WithRSA
+
+	c, _ = g.NewFakeContext("/", &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}})
+	is.NoErr(h(c))
+
+	cert.SignatureAlgorithm = x509.ECDSAWithSHA256
+	c, _ = g.NewFakeContext("/", &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}})
+	is.Equal(StatusCertificateNotValid, h(c).(*GeminiError).Code)
+}
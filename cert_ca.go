@@ -0,0 +1,224 @@
+package gig
+
+import (
+	"bufio"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// CertAuthCAOptions defines options of CertAuthCA validator.
+	CertAuthCAOptions struct {
+		// KeyUsages lists accepted extended key usages of client certificate.
+		// Optional. Default value is x509.ExtKeyUsageClientAuth.
+		KeyUsages []x509.ExtKeyUsage
+
+		// CRLFiles are paths to certificate revocation lists in PEM or DER
+		// format. Files are reloaded when they change.
+		// Optional.
+		CRLFiles []string
+
+		// AllowedSubjects lists accepted subject common names. See
+		// `LoadCertAllowList()`.
+		// Optional. Default value accepts any subject.
+		AllowedSubjects []string
+
+		// AllowedOUs lists accepted subject organizational units, certificate
+		// must have at least one of them.
+		// Optional. Default value accepts any organizational unit.
+		AllowedOUs []string
+	}
+
+	// crlFile is a revocation list loaded from disk.
+	crlFile struct {
+		path    string
+		mu      sync.Mutex
+		modTime time.Time
+		crl     *pkix.CertificateList
+		revoked map[string]bool // Serial numbers
+	}
+)
+
+// CertAuthCA returns a validator that verifies client certificate chain
+// against CA pool, checking extended key usage, revocation and allowed
+// subjects. Certificates that are expired, malformed or revoked are rejected
+// with ErrCertificateNotValid, certificates that are not issued by CA or not
+// allowed are rejected with ErrCertificateNotAuthorised. Like
+// ValidateHasCertificate, it stores subject name in context under "subject".
+//
+// It panics if CRL files cannot be loaded.
+func CertAuthCA(pool *x509.CertPool, opts CertAuthCAOptions) CertAuthValidator {
+	if len(opts.KeyUsages) == 0 {
+		opts.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	}
+
+	crls := make([]*crlFile, len(opts.CRLFiles))
+
+	for i, path := range opts.CRLFiles {
+		crls[i] = &crlFile{path: path}
+		if err := crls[i].load(); err != nil {
+			panic(fmt.Sprintf("gig: could not load CRL: %s", err))
+		}
+	}
+
+	return func(cert *x509.Certificate, c Context) *GeminiError {
+		if err := ValidateHasCertificate(cert, c); err != nil {
+			return err
+		}
+
+		intermediates := x509.NewCertPool()
+
+		if chain := c.CertificateChain(); len(chain) > 0 && chain[0] == cert {
+			for _, ic := range chain[1:] {
+				intermediates.AddCert(ic)
+			}
+		}
+
+		chains, err := cert.Verify(x509.VerifyOptions{
+			Roots:         pool,
+			Intermediates: intermediates,
+			KeyUsages:     opts.KeyUsages,
+		})
+		if err != nil {
+			return verifyError(err, c)
+		}
+
+		for _, crl := range crls {
+			if crl.revokes(cert, chains[0], c.Gig().logger()) {
+				return NewErrorFrom(ErrCertificateNotValid, "Certificate has been revoked")
+			}
+		}
+
+		if len(opts.AllowedSubjects) > 0 && !containsString(opts.AllowedSubjects, cert.Subject.CommonName) {
+			return NewErrorFrom(ErrCertificateNotAuthorised, "Subject is not allowed")
+		}
+
+		if len(opts.AllowedOUs) > 0 {
+			allowed := false
+
+			for _, ou := range cert.Subject.OrganizationalUnit {
+				if containsString(opts.AllowedOUs, ou) {
+					allowed = true
+					break
+				}
+			}
+
+			if !allowed {
+				return NewErrorFrom(ErrCertificateNotAuthorised, "Organizational unit is not allowed")
+			}
+		}
+
+		return nil
+	}
+}
+
+// LoadCertAllowList reads allowed subjects or organizational units from file,
+// one per line. Empty lines and lines starting with # are ignored.
+func LoadCertAllowList(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var (
+		list []string
+		s    = bufio.NewScanner(f)
+	)
+
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		list = append(list, line)
+	}
+
+	return list, s.Err()
+}
+
+// verifyError maps certificate verification error to GeminiError, logging
+// errors it does not recognise.
+func verifyError(err error, c Context) *GeminiError {
+	switch e := err.(type) {
+	case x509.CertificateInvalidError:
+		switch e.Reason {
+		case x509.Expired:
+			return NewErrorFrom(ErrCertificateNotValid, "Certificate has expired or is not valid yet")
+		case x509.IncompatibleUsage:
+			return NewErrorFrom(ErrCertificateNotAuthorised, "Certificate is not allowed for client authentication")
+		case x509.NotAuthorizedToSign, x509.CANotAuthorizedForThisName, x509.CANotAuthorizedForExtKeyUsage:
+			return NewErrorFrom(ErrCertificateNotAuthorised, "Certificate is not issued by a trusted CA")
+		}
+	case x509.UnknownAuthorityError:
+		return NewErrorFrom(ErrCertificateNotAuthorised, "Certificate is not issued by a trusted CA")
+	}
+
+	c.Gig().logger().Warn("could not verify client certificate", "remote_ip", c.IP(), "error", err)
+
+	return NewErrorFrom(ErrCertificateNotValid, "Certificate could not be verified")
+}
+
+// revokes reports whether cert is listed in CRL issued by its issuer from
+// verified chain. CRL is reloaded first if file has changed, reload errors are
+// logged to log.
+func (f *crlFile) revokes(cert *x509.Certificate, chain []*x509.Certificate, log LeveledLogger) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if info, err := os.Stat(f.path); err == nil && !info.ModTime().Equal(f.modTime) {
+		if err := f.load(); err != nil {
+			log.Error("could not reload CRL, using previous", "file", f.path, "error", err)
+		}
+	}
+
+	if len(chain) < 2 || !f.revoked[cert.SerialNumber.String()] {
+		return false
+	}
+
+	// CRL applies only if it is signed by issuer of cert
+	return chain[1].CheckCRLSignature(f.crl) == nil
+}
+
+func (f *crlFile) load() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+
+	b, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+
+	crl, err := x509.ParseCRL(b)
+	if err != nil {
+		return fmt.Errorf("%s: %w", f.path, err)
+	}
+
+	revoked := make(map[string]bool, len(crl.TBSCertList.RevokedCertificates))
+	for _, r := range crl.TBSCertList.RevokedCertificates {
+		revoked[r.SerialNumber.String()] = true
+	}
+
+	f.crl, f.revoked, f.modTime = crl, revoked, info.ModTime()
+
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,185 @@
+package gig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+type testCA struct {
+	cert   *x509.Certificate
+	key    *ecdsa.PrivateKey
+	serial int64
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testCA{cert: cert, key: key, serial: 1}
+}
+
+func (ca *testCA) issue(t *testing.T, subject pkix.Name, usage x509.ExtKeyUsage, notAfter time.Time) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ca.serial++
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(ca.serial),
+		Subject:      subject,
+		NotBefore:    time.Now().Add(-2 * time.Hour),
+		NotAfter:     notAfter,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert
+}
+
+func (ca *testCA) crl(t *testing.T, revoked ...*x509.Certificate) []byte {
+	var list []pkix.RevokedCertificate
+	for _, c := range revoked {
+		list = append(list, pkix.RevokedCertificate{SerialNumber: c.SerialNumber, RevocationTime: time.Now()})
+	}
+
+	der, err := ca.cert.CreateCRL(rand.Reader, ca.key, list, time.Now(), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return der
+}
+
+func TestCertAuthCA(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := ioutil.TempDir("", "gig")
+	is.NoErr(err)
+
+	defer os.RemoveAll(dir)
+
+	var (
+		g       = New()
+		ca      = newTestCA(t)
+		other   = newTestCA(t)
+		later   = time.Now().Add(time.Hour)
+		alice   = ca.issue(t, pkix.Name{CommonName: "alice", OrganizationalUnit: []string{"ops"}}, x509.ExtKeyUsageClientAuth, later)
+		bob     = ca.issue(t, pkix.Name{CommonName: "bob", OrganizationalUnit: []string{"ops"}}, x509.ExtKeyUsageClientAuth, later)
+		carol   = ca.issue(t, pkix.Name{CommonName: "carol", OrganizationalUnit: []string{"dev"}}, x509.ExtKeyUsageClientAuth, later)
+		server  = ca.issue(t, pkix.Name{CommonName: "alice"}, x509.ExtKeyUsageServerAuth, later)
+		expired = ca.issue(t, pkix.Name{CommonName: "alice"}, x509.ExtKeyUsageClientAuth, time.Now().Add(-time.Hour))
+		eve     = other.issue(t, pkix.Name{CommonName: "alice"}, x509.ExtKeyUsageClientAuth, later)
+		crlPath = filepath.Join(dir, "ca.crl")
+		allowed = filepath.Join(dir, "subjects.txt")
+		pool    = x509.NewCertPool()
+	)
+
+	pool.AddCert(ca.cert)
+	is.NoErr(ioutil.WriteFile(crlPath, ca.crl(t, bob), 0600))
+	is.NoErr(ioutil.WriteFile(allowed, []byte("# Allowed\nalice\n\n bob \ncarol\n"), 0600))
+
+	subjects, err := LoadCertAllowList(allowed)
+	is.NoErr(err)
+	is.Equal([]string{"alice", "bob", "carol"}, subjects)
+
+	h := CertAuth(CertAuthCA(pool, CertAuthCAOptions{
+		CRLFiles:        []string{crlPath},
+		AllowedSubjects: subjects[:2],
+		AllowedOUs:      []string{"ops", "dev"},
+	}))(func(c Context) error {
+		return c.Gemini("%s", c.Get("subject"))
+	})
+
+	check := func(cert *x509.Certificate) string {
+		c, conn := g.NewFakeContext("/", &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}})
+		if err := h(c); err != nil {
+			g.GeminiErrorHandler(err, c)
+		}
+
+		return conn.Written
+	}
+
+	is.Equal("20 text/gemini\r\nalice", check(alice))
+	is.Equal("62 Certificate has been revoked\r\n", check(bob))
+	is.Equal("61 Subject is not allowed\r\n", check(carol))
+	is.Equal("61 Certificate is not allowed for client authentication\r\n", check(server))
+	is.Equal("62 Certificate has expired or is not valid yet\r\n", check(expired))
+	is.Equal("61 Certificate is not issued by a trusted CA\r\n", check(eve))
+
+	// CRL is reloaded when changed
+	is.NoErr(ioutil.WriteFile(crlPath, ca.crl(t, alice), 0600))
+	is.NoErr(os.Chtimes(crlPath, later, later))
+	is.Equal("62 Certificate has been revoked\r\n", check(alice))
+
+	// Previous CRL is used if reload fails
+	logger := &recordLogger{}
+	g.Logger = logger
+
+	is.NoErr(ioutil.WriteFile(crlPath, []byte("garbage"), 0600))
+	is.NoErr(os.Chtimes(crlPath, later.Add(time.Hour), later.Add(time.Hour)))
+	is.Equal("62 Certificate has been revoked\r\n", check(alice))
+	is.True(logger.has("ERROR could not reload CRL, using previous"))
+
+	// CRL of other CA does not apply
+	is.NoErr(ioutil.WriteFile(crlPath, other.crl(t, bob), 0600))
+
+	h = CertAuth(CertAuthCA(pool, CertAuthCAOptions{
+		CRLFiles:   []string{crlPath},
+		AllowedOUs: []string{"ops"},
+	}))(func(c Context) error {
+		return c.Gemini("ok")
+	})
+	is.Equal("20 text/gemini\r\nok", check(bob))
+	is.Equal("61 Organizational unit is not allowed\r\n", check(carol))
+
+	defer func() {
+		is.True(recover() != nil)
+	}()
+
+	CertAuthCA(pool, CertAuthCAOptions{CRLFiles: []string{allowed}})
+}
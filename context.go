@@ -1,7 +1,6 @@
 package gig
 
 import (
-	"crypto/md5"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
@@ -14,6 +13,7 @@ import (
 	"path"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 )
@@ -29,12 +29,24 @@ type (
 		// IP returns the client's network address.
 		IP() string
 
+		// ConnectionState returns TLS state of the connection, or nil if none.
+		ConnectionState() *tls.ConnectionState
+
 		// Certificate returns client's leaf certificate or nil if none provided
 		Certificate() *x509.Certificate
 
-		// CertHash returns a hash of client's leaf certificate or empty string is none
+		// CertificateChain returns all certificates sent by client, leaf first,
+		// or nil if none provided
+		CertificateChain() []*x509.Certificate
+
+		// CertHash returns MD5 hash of client's leaf certificate or empty string is none.
+		// Prefer CertFingerprint for new code.
 		CertHash() string
 
+		// CertFingerprint returns fingerprint of client's leaf certificate using alg
+		// or empty string if none.
+		CertFingerprint(alg FingerprintAlgorithm) string
+
 		// URL returns the URL for the context.
 		URL() *url.URL
 
@@ -45,6 +57,19 @@ type (
 		// could not be unescaped. Use Context#URL().RawQuery to get raw query string.
 		QueryString() (string, error)
 
+		// QueryParams returns query string parsed as `key=value&...` pairs.
+		// Malformed pairs are ignored.
+		QueryParams() url.Values
+
+		// QueryParam returns the first value of query param by name.
+		QueryParam(name string) string
+
+		// Bind populates fields of struct pointed to by i from path params and
+		// query params using `param:"name"` and `query:"name"` tags. A tag may
+		// end with ",required". Missing or invalid values are returned as
+		// ErrBadRequest.
+		Bind(i interface{}) error
+
 		// RequestURI is the unmodified URL string as sent by the client
 		// to a server. Usually the URL() or Path() should be used instead.
 		RequestURI() string
@@ -52,6 +77,14 @@ type (
 		// Param returns path parameter by name.
 		Param(name string) string
 
+		// ParamInt returns path parameter by name converted to int. Conversion
+		// errors are returned as ErrBadRequest.
+		ParamInt(name string) (int, error)
+
+		// ParamInt64 returns path parameter by name converted to int64. Conversion
+		// errors are returned as ErrBadRequest.
+		ParamInt64(name string) (int64, error)
+
 		// Get retrieves data from the context.
 		Get(key string) interface{}
 
@@ -84,6 +117,14 @@ type (
 		// Use for any non-2x status codes
 		NoContent(code Status, meta string, values ...interface{}) error
 
+		// Redirect sends a redirect response with a 3x status code. Relative
+		// targets are resolved against URL().
+		Redirect(code Status, url string) error
+
+		// RedirectToRoute sends a temporary redirect to the route with provided
+		// name, generating its URL with `Gig#Reverse`.
+		RedirectToRoute(name string, params ...interface{}) error
+
 		// Error invokes the registered error handler. Generally used by middleware.
 		Error(err error)
 
@@ -98,14 +139,20 @@ type (
 		conn       tlsconn
 		TLS        *tls.ConnectionState
 		u          *url.URL
+		outer      *url.URL // URL before mount prefix was stripped, nil if not mounted
 		response   *Response
 		path       string
 		requestURI string
 		pnames     []string
 		pvalues    []string
+		hnames     []string // Host param names
+		hvalues    []string // Host param values
+		query      url.Values
 		handler    HandlerFunc
+		matched    bool // Whether a route was found for the request
 		store      storeMap
 		gig        *Gig
+		group      *Group
 		lock       sync.RWMutex
 	}
 )
@@ -123,6 +170,18 @@ func (c *context) IP() string {
 	return ra
 }
 
+func (c *context) ConnectionState() *tls.ConnectionState {
+	return c.TLS
+}
+
+func (c *context) CertificateChain() []*x509.Certificate {
+	if c.TLS == nil {
+		return nil
+	}
+
+	return c.TLS.PeerCertificates
+}
+
 func (c *context) Certificate() *x509.Certificate {
 	if c.TLS == nil || len(c.TLS.PeerCertificates) == 0 {
 		return nil
@@ -132,12 +191,11 @@ func (c *context) Certificate() *x509.Certificate {
 }
 
 func (c *context) CertHash() string {
-	cert := c.Certificate()
-	if cert == nil {
-		return ""
-	}
+	return CertFingerprint(c.Certificate(), FingerprintMD5)
+}
 
-	return fmt.Sprintf("%x", md5.Sum(cert.Raw))
+func (c *context) CertFingerprint(alg FingerprintAlgorithm) string {
+	return CertFingerprint(c.Certificate(), alg)
 }
 
 func (c *context) URL() *url.URL {
@@ -161,13 +219,53 @@ func (c *context) Param(name string) string {
 		}
 	}
 
+	for i, n := range c.hnames {
+		if n == name {
+			return c.hvalues[i]
+		}
+	}
+
 	return ""
 }
 
+func (c *context) ParamInt(name string) (int, error) {
+	i, err := strconv.Atoi(c.Param(name))
+	if err != nil {
+		return 0, NewErrorFrom(ErrBadRequest, fmt.Sprintf("Invalid %s", name))
+	}
+
+	return i, nil
+}
+
+func (c *context) ParamInt64(name string) (int64, error) {
+	i, err := strconv.ParseInt(c.Param(name), 10, 64)
+	if err != nil {
+		return 0, NewErrorFrom(ErrBadRequest, fmt.Sprintf("Invalid %s", name))
+	}
+
+	return i, nil
+}
+
 func (c *context) QueryString() (string, error) {
 	return url.QueryUnescape(c.u.RawQuery)
 }
 
+func (c *context) QueryParams() url.Values {
+	if c.query == nil {
+		c.query, _ = url.ParseQuery(c.u.RawQuery)
+	}
+
+	return c.query
+}
+
+func (c *context) QueryParam(name string) string {
+	return c.QueryParams().Get(name)
+}
+
+func (c *context) Bind(i interface{}) error {
+	return bind(c, i)
+}
+
 func (c *context) Get(key string) interface{} {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
@@ -268,7 +366,9 @@ func (c *context) File(file string) (err error) {
 			return err
 		}
 
-		_, _ = c.response.Write([]byte(fmt.Sprintf("# Listing %s\n\n", c.u.Path)))
+		dir := c.gig.mountPath() + c.u.Path
+
+		_, _ = c.response.Write([]byte(fmt.Sprintf("# Listing %s\n\n", dir)))
 
 		sort.Slice(files, func(i, j int) bool { return files[i].Name() < files[j].Name() })
 
@@ -281,7 +381,7 @@ func (c *context) File(file string) (err error) {
 				continue
 			}
 
-			_, _ = c.response.Write([]byte(fmt.Sprintf("=> %s %s [ %v ]\n", filepath.Clean(path.Join(c.u.Path, file.Name())), file.Name(), bytefmt(file.Size()))))
+			_, _ = c.response.Write([]byte(fmt.Sprintf("=> %s %s [ %v ]\n", filepath.Clean(path.Join(dir, file.Name())), file.Name(), bytefmt(file.Size()))))
 		}
 
 		return nil
@@ -343,7 +443,61 @@ func (c *context) NoContent(code Status, meta string, values ...interface{}) err
 	return c.response.WriteHeader(code, fmt.Sprintf(meta, values...))
 }
 
+func (c *context) Redirect(code Status, target string) error {
+	if code < StatusRedirectTemporary || code > 39 {
+		return ErrInvalidRedirectCode
+	}
+
+	ref, err := url.Parse(target)
+	if err != nil {
+		return err
+	}
+
+	// Resolve against URL client requested, so that relative targets of
+	// mounted Gig stay under its prefix
+	base := c.outerURL()
+
+	u := ref
+	if base != nil {
+		u = base.ResolveReference(ref)
+
+		if u.String() == base.String() {
+			return ErrRedirectLoop
+		}
+	}
+
+	return c.response.WriteHeader(code, u.String())
+}
+
+// outerURL returns URL as requested by client, before any mount prefix was
+// stripped from it.
+func (c *context) outerURL() *url.URL {
+	if c.outer != nil {
+		return c.outer
+	}
+
+	return c.u
+}
+
+func (c *context) RedirectToRoute(name string, params ...interface{}) error {
+	uri := c.gig.Reverse(name, params...)
+	if uri == "" {
+		return ErrRouteNotFound
+	}
+
+	return c.Redirect(StatusRedirectTemporary, uri)
+}
+
 func (c *context) Error(err error) {
+	if h := c.gig.root().OnError; h != nil {
+		h(c, err)
+	}
+
+	if c.group != nil {
+		c.group.geminiErrorHandler()(err, c)
+		return
+	}
+
 	c.gig.GeminiErrorHandler(err, c)
 }
 
@@ -359,12 +513,18 @@ func (c *context) reset(conn tlsconn, u *url.URL, requestURI string, tls *tls.Co
 	c.conn = conn
 	c.TLS = tls
 	c.u = u
+	c.outer = nil
 	c.requestURI = requestURI
 	c.response.reset(conn)
 	c.handler = NotFoundHandler
+	c.matched = false
+	c.group = nil
 	c.store = nil
 	c.path = ""
 	c.pnames = nil
+	c.hnames = nil
+	c.hvalues = nil
+	c.query = nil
 	// NOTE: Don't reset because it has to have length c.gig.maxParam at all times
 	for i := 0; i < *c.gig.maxParam; i++ {
 		c.pvalues[i] = ""
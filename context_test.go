@@ -263,3 +263,30 @@ func TestContext_bytefmt(t *testing.T) {
 
 	is.Equal(bytefmt(12345678), "12.3MB")
 }
+
+func TestContextRedirect(t *testing.T) {
+	is := is.New(t)
+
+	g := New()
+	g.Handle("/users/:name", func(Context) error { return nil }).SetName("user")
+
+	c, conn := g.NewFakeContext("gemini://example.org/a/b", nil)
+	is.NoErr(c.Redirect(StatusRedirectPermanent, "c"))
+	is.Equal("31 gemini://example.org/a/c\r\n", conn.Written)
+
+	c, conn = g.NewFakeContext("/old", nil)
+	is.NoErr(c.Redirect(StatusRedirectTemporary, "/new"))
+	is.Equal("30 /new\r\n", conn.Written)
+
+	c, _ = g.NewFakeContext("/old", nil)
+	is.Equal(ErrInvalidRedirectCode, c.Redirect(StatusSuccess, "/new"))
+	is.Equal(ErrRedirectLoop, c.Redirect(StatusRedirectTemporary, "/old"))
+	is.True(c.Redirect(StatusRedirectTemporary, "%%") != nil)
+
+	c, conn = g.NewFakeContext("/", nil)
+	is.NoErr(c.RedirectToRoute("user", "jon"))
+	is.Equal("30 /users/jon\r\n", conn.Written)
+
+	c, _ = g.NewFakeContext("/", nil)
+	is.Equal(ErrRouteNotFound, c.RedirectToRoute("missing"))
+}
@@ -0,0 +1,353 @@
+package gig
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// FileLoggerConfig defines the config for FileLogger.
+	FileLoggerConfig struct {
+		// Filename is the file to write to. Rotated files are kept next to it
+		// with a timestamp suffix, e.g. access.log.20201231-235959.
+		// Required.
+		Filename string
+
+		// MaxSize is the size in bytes file is rotated at.
+		// Optional. Default value 0, no rotation by size.
+		MaxSize int64
+
+		// RotateEvery rotates file when a multiple of duration since zero
+		// time (UTC) passes, e.g. at midnight UTC for 24 hours.
+		// Optional. Default value 0, no rotation by time.
+		RotateEvery time.Duration
+
+		// MaxBackups is the number of rotated files to keep.
+		// Optional. Default value 0, keep all.
+		MaxBackups int
+
+		// Compress gzips rotated files.
+		// Optional. Default value false.
+		Compress bool
+
+		// Logger receives errors that happen in background, while
+		// compressing or removing rotated files or reopening file on SIGHUP.
+		// Optional. Default value prints them while Debug is true.
+		Logger LeveledLogger
+	}
+
+	// FileLogger is an io.Writer that appends to a file, rotating it by size
+	// or time. It reopens file on SIGHUP, so that it can also be rotated by
+	// external tools such as logrotate. It is safe for concurrent use.
+	FileLogger struct {
+		config FileLoggerConfig
+		mu     sync.Mutex
+		file   *os.File
+		size   int64
+		opened time.Time
+		mill   sync.Mutex // Serializes compression and removal of backups
+		wg     sync.WaitGroup
+		stop   func()
+	}
+)
+
+const backupTimeFormat = "20060102-150405"
+
+// NewFileLogger opens config.Filename for appending, creating it if needed,
+// and returns a FileLogger that writes to it. Use it as `LoggerConfig.Output`.
+func NewFileLogger(config FileLoggerConfig) (*FileLogger, error) {
+	if config.Filename == "" {
+		return nil, fmt.Errorf("gig: file logger requires a filename")
+	}
+
+	if config.Logger == nil {
+		config.Logger = debugLogger{}
+	}
+
+	l := &FileLogger{config: config}
+
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+
+	l.stop = notifyReopen(l)
+
+	return l, nil
+}
+
+// Write implements io.Writer, rotating file first if needed.
+func (l *FileLogger) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if l.due(len(p)) {
+		if err := l.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := l.file.Write(p)
+	l.size += int64(n)
+
+	return n, err
+}
+
+// Rotate renames current file to a backup and opens a new one.
+func (l *FileLogger) Rotate() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.rotate()
+}
+
+// Reopen closes and opens file again, after it was moved away by another
+// tool.
+func (l *FileLogger) Reopen() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return os.ErrClosed
+	}
+
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+
+	return l.open()
+}
+
+// Close stops listening for SIGHUP, waits for compression of rotated files and
+// closes file.
+func (l *FileLogger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+
+	l.stop()
+	l.wg.Wait()
+
+	err := l.file.Close()
+	l.file = nil
+
+	return err
+}
+
+// due reports whether file must be rotated before writing n bytes.
+func (l *FileLogger) due(n int) bool {
+	if l.config.MaxSize > 0 && l.size > 0 && l.size+int64(n) > l.config.MaxSize {
+		return true
+	}
+
+	if every := l.config.RotateEvery; every > 0 {
+		return !time.Now().Truncate(every).Equal(l.opened.Truncate(every))
+	}
+
+	return false
+}
+
+func (l *FileLogger) open() error {
+	if err := os.MkdirAll(filepath.Dir(l.config.Filename), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(l.config.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	l.file = f
+	l.size = info.Size()
+	l.opened = time.Now()
+
+	// File written before restart is rotated at the next time boundary
+	if l.size > 0 {
+		l.opened = info.ModTime()
+	}
+
+	return nil
+}
+
+func (l *FileLogger) rotate() error {
+	if l.file == nil {
+		return os.ErrClosed
+	}
+
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+
+	backup := l.backupName()
+	if err := os.Rename(l.config.Filename, backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := l.open(); err != nil {
+		return err
+	}
+
+	l.wg.Add(1)
+
+	go func() {
+		defer l.wg.Done()
+
+		l.mill.Lock()
+		defer l.mill.Unlock()
+
+		if l.config.Compress {
+			if err := compressFile(backup); err != nil {
+				l.config.Logger.Error("could not compress log file", "file", backup, "error", err)
+			}
+		}
+
+		if err := l.prune(); err != nil {
+			l.config.Logger.Error("could not remove old log files", "file", l.config.Filename, "error", err)
+		}
+	}()
+
+	return nil
+}
+
+// backupName returns unused name for file rotated now.
+func (l *FileLogger) backupName() string {
+	now := time.Now().Format(backupTimeFormat)
+	base := l.config.Filename + "." + now
+
+	// Number past every backup of the same second, as older ones may have
+	// been pruned already and reusing their name would break ordering
+	n := 0
+
+	if backups, err := l.backups(); err == nil {
+		for _, b := range backups {
+			if stamp, i := l.backupOrder(b); stamp == now && i >= n {
+				n = i + 1
+			}
+		}
+	}
+
+	if n == 0 {
+		return base
+	}
+
+	return fmt.Sprintf("%s.%d", base, n)
+}
+
+// backupOrder returns timestamp and sequence number of backup name.
+func (l *FileLogger) backupOrder(name string) (string, int) {
+	suffix := strings.TrimSuffix(strings.TrimPrefix(name, l.config.Filename+"."), ".gz")
+	stamp := suffix[:len(backupTimeFormat)]
+
+	i, err := strconv.Atoi(strings.TrimPrefix(suffix[len(stamp):], "."))
+	if err != nil {
+		return stamp, 0
+	}
+
+	return stamp, i
+}
+
+// prune removes oldest backups above MaxBackups.
+func (l *FileLogger) prune() error {
+	if l.config.MaxBackups <= 0 {
+		return nil
+	}
+
+	backups, err := l.backups()
+	if err != nil {
+		return err
+	}
+
+	for len(backups) > l.config.MaxBackups {
+		if err := os.Remove(backups[0]); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		backups = backups[1:]
+	}
+
+	return nil
+}
+
+// backups returns rotated files, oldest first.
+func (l *FileLogger) backups() ([]string, error) {
+	files, err := filepath.Glob(l.config.Filename + ".*")
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []string
+
+	for _, f := range files {
+		suffix := strings.TrimPrefix(f, l.config.Filename+".")
+		if len(suffix) < len(backupTimeFormat) {
+			continue
+		}
+
+		if _, err := time.Parse(backupTimeFormat, suffix[:len(backupTimeFormat)]); err == nil {
+			backups = append(backups, f)
+		}
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		si, ni := l.backupOrder(backups[i])
+		sj, nj := l.backupOrder(backups[j])
+
+		if si != sj {
+			return si < sj
+		}
+
+		return ni < nj
+	})
+
+	return backups, nil
+}
+
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(dst)
+
+	if _, err = io.Copy(zw, src); err == nil {
+		err = zw.Close()
+	}
+
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		os.Remove(name + ".gz")
+		return err
+	}
+
+	return os.Remove(name)
+}
@@ -0,0 +1,9 @@
+//go:build windows || plan9 || js
+// +build windows plan9 js
+
+package gig
+
+// notifyReopen does nothing, as there is no SIGHUP on this platform.
+func notifyReopen(l *FileLogger) func() {
+	return func() {}
+}
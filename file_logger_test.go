@@ -0,0 +1,119 @@
+package gig
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestFileLogger(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := ioutil.TempDir("", "gig-log")
+	is.NoErr(err)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "logs", "access.log")
+	l, err := NewFileLogger(FileLoggerConfig{Filename: name, MaxSize: 5, MaxBackups: 2})
+	is.NoErr(err)
+
+	for _, line := range []string{"one\n", "two\n", "three\n", "four\n", "five\n"} {
+		_, err = l.Write([]byte(line))
+		is.NoErr(err)
+	}
+
+	is.NoErr(l.Close())
+
+	_, err = l.Write([]byte("closed\n"))
+	is.Equal(os.ErrClosed, err)
+
+	b, err := ioutil.ReadFile(name)
+	is.NoErr(err)
+	is.Equal("five\n", string(b))
+
+	backups, err := l.backups()
+	is.NoErr(err)
+	is.Equal(2, len(backups))
+
+	b, err = ioutil.ReadFile(backups[1])
+	is.NoErr(err)
+	is.Equal("four\n", string(b))
+}
+
+func TestFileLogger_Compress(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := ioutil.TempDir("", "gig-log")
+	is.NoErr(err)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "access.log")
+	l, err := NewFileLogger(FileLoggerConfig{Filename: name, RotateEvery: time.Hour, Compress: true})
+	is.NoErr(err)
+
+	_, err = l.Write([]byte("yesterday\n"))
+	is.NoErr(err)
+
+	// Pretend file was opened in previous period
+	l.mu.Lock()
+	l.opened = l.opened.Add(-time.Hour)
+	l.mu.Unlock()
+
+	_, err = l.Write([]byte("today\n"))
+	is.NoErr(err)
+	is.NoErr(l.Close())
+
+	backups, err := l.backups()
+	is.NoErr(err)
+	is.Equal(1, len(backups))
+	is.True(strings.HasSuffix(backups[0], ".gz"))
+
+	f, err := os.Open(backups[0])
+	is.NoErr(err)
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	is.NoErr(err)
+
+	b, err := ioutil.ReadAll(zr)
+	is.NoErr(err)
+	is.Equal("yesterday\n", string(b))
+}
+
+func TestFileLogger_Reopen(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := ioutil.TempDir("", "gig-log")
+	is.NoErr(err)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "access.log")
+	l, err := NewFileLogger(FileLoggerConfig{Filename: name})
+	is.NoErr(err)
+
+	defer l.Close()
+
+	_, err = l.Write([]byte("before\n"))
+	is.NoErr(err)
+
+	// Moved away by logrotate
+	is.NoErr(os.Rename(name, name+".1"))
+	is.NoErr(l.Reopen())
+
+	_, err = l.Write([]byte("after\n"))
+	is.NoErr(err)
+
+	b, err := ioutil.ReadFile(name + ".1")
+	is.NoErr(err)
+	is.Equal("before\n", string(b))
+
+	b, err = ioutil.ReadFile(name)
+	is.NoErr(err)
+	is.Equal("after\n", string(b))
+}
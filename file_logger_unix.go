@@ -0,0 +1,38 @@
+//go:build !windows && !plan9 && !js
+// +build !windows,!plan9,!js
+
+package gig
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyReopen reopens l on SIGHUP until returned function is called.
+func notifyReopen(l *FileLogger) func() {
+	var (
+		sig  = make(chan os.Signal, 1)
+		done = make(chan struct{})
+	)
+
+	signal.Notify(sig, syscall.SIGHUP)
+
+	go func() {
+		for {
+			select {
+			case <-sig:
+				if err := l.Reopen(); err != nil {
+					l.config.Logger.Error("could not reopen log file", "file", l.config.Filename, "error", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sig)
+		close(done)
+	}
+}
@@ -0,0 +1,54 @@
+package gig
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+)
+
+// FingerprintAlgorithm defines how certificate fingerprint is computed.
+type FingerprintAlgorithm uint8
+
+// Fingerprint algorithms.
+const (
+	// FingerprintSHA256 hashes the whole certificate using SHA-256. This is
+	// what most Gemini clients and servers display.
+	FingerprintSHA256 FingerprintAlgorithm = iota
+	// FingerprintSHA1 hashes the whole certificate using SHA-1.
+	FingerprintSHA1
+	// FingerprintMD5 hashes the whole certificate using MD5, as
+	// `Context#CertHash()` does. Only use it for existing data.
+	FingerprintMD5
+	// FingerprintPublicKeySHA256 hashes only the public key using SHA-256, so
+	// that certificates re-issued for the same key have the same fingerprint.
+	FingerprintPublicKeySHA256
+)
+
+// CertFingerprint returns hex encoded fingerprint of cert using alg, or empty
+// string if cert is nil.
+func CertFingerprint(cert *x509.Certificate, alg FingerprintAlgorithm) string {
+	if cert == nil {
+		return ""
+	}
+
+	var sum []byte
+
+	switch alg {
+	case FingerprintSHA1:
+		s := sha1.Sum(cert.Raw)
+		sum = s[:]
+	case FingerprintMD5:
+		s := md5.Sum(cert.Raw)
+		sum = s[:]
+	case FingerprintPublicKeySHA256:
+		s := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		sum = s[:]
+	default:
+		s := sha256.Sum256(cert.Raw)
+		sum = s[:]
+	}
+
+	return hex.EncodeToString(sum)
+}
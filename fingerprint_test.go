@@ -0,0 +1,28 @@
+package gig
+
+import (
+	"crypto/x509"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestCertFingerprint(t *testing.T) {
+	is := is.New(t)
+
+	var (
+		cert    = &x509.Certificate{Raw: []byte{1}, RawSubjectPublicKeyInfo: []byte{2}}
+		renewed = &x509.Certificate{Raw: []byte{3}, RawSubjectPublicKeyInfo: []byte{2}}
+	)
+
+	is.Equal("4bf5122f344554c53bde2ebb8cd2b7e3d1600ad631c385a5d7cce23c7785459a", CertFingerprint(cert, FingerprintSHA256))
+	is.Equal("bf8b4530d8d246dd74ac53a13471bba17941dff7", CertFingerprint(cert, FingerprintSHA1))
+	is.Equal("55a54008ad1ba589aa210d2629c1df41", CertFingerprint(cert, FingerprintMD5))
+	is.Equal(CertFingerprint(cert, FingerprintPublicKeySHA256), CertFingerprint(renewed, FingerprintPublicKeySHA256))
+	is.True(CertFingerprint(cert, FingerprintSHA256) != CertFingerprint(renewed, FingerprintSHA256))
+	is.Equal("", CertFingerprint(nil, FingerprintSHA256))
+
+	c, _ := New().NewFakeContext("/", nil)
+	is.Equal("", c.CertFingerprint(FingerprintSHA256))
+	is.Equal("", c.CertHash())
+}
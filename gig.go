@@ -39,6 +39,8 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -52,6 +54,7 @@ type (
 		middleware    []MiddlewareFunc
 		maxParam      *int
 		router        *router
+		hosts         []*router
 		listener      net.Listener
 		addr          string
 		ctxpool       sync.Pool
@@ -59,6 +62,8 @@ type (
 		doneChan      chan struct{}
 		closeOnce     sync.Once
 		mu            sync.Mutex
+		parent        *Gig   // Gig this instance is mounted onto
+		prefix        string // Path prefix this instance is mounted under
 
 		// HideBanner disables banner on startup.
 		HideBanner bool
@@ -77,12 +82,55 @@ type (
 		// TLSConfig is passed to tls.NewListener and needs to be modified
 		// before Run is called.
 		TLSConfig *tls.Config
+		// PanicOnConflict makes adding a route that shadows an existing one
+		// panic. By default the last route wins, see `Gig#Validate()`.
+		PanicOnConflict bool
+		// Logger receives diagnostics such as accept errors, TLS handshake
+		// failures and invalid requests. Mounted instances use Logger of
+		// their parent unless set.
+		// Default writes to DefaultWriter if Debug is true.
+		Logger LeveledLogger
+		// Metrics counts connections, TLS handshake failures and invalid
+		// requests, and receives observations of Metrics middleware.
+		// Mounted instances use Metrics of their parent unless set.
+		// Default is none.
+		Metrics *MetricsRegistry
+
+		// Hooks are called at stages of serving a connection, e.g. to trace
+		// requests. Durations are measured from accepting the connection.
+		// Hooks of mounted instances are not called, only those of the
+		// instance that serves connections.
+
+		// OnAccept is called for every accepted TLS connection.
+		OnAccept func(conn net.Conn)
+		// OnHandshake is called after TLS handshake, err is set if it failed.
+		OnHandshake func(conn net.Conn, d time.Duration, err error)
+		// OnRequestParsed is called once request line is read and parsed.
+		OnRequestParsed func(c Context, d time.Duration)
+		// OnResponseHeader is called after response header is written.
+		OnResponseHeader func(c Context, status Status, meta string, d time.Duration)
+		// OnResponseDone is called after request is served.
+		OnResponseDone func(c Context, d time.Duration)
+		// OnError is called with errors passed to `Context#Error()`, before
+		// they are handled.
+		OnError func(c Context, err error)
 	}
 
 	// Route contains a handler and information for matching against requests.
 	Route struct {
 		Path string
 		Name string
+		// Host is set for routes added using `Gig#Host()`.
+		Host string
+		// Title and Description describe route in listings such as Sitemap.
+		Title       string
+		Description string
+		// Hidden excludes route from listings such as Sitemap.
+		Hidden bool
+
+		router *router
+		root   string // Directory served by Static
+		mount  bool   // Route hands requests off to a mounted Gig
 	}
 
 	// GeminiError represents an error that occurred while handling a request.
@@ -151,6 +199,11 @@ var (
 
 	ErrRendererNotRegistered = errors.New("renderer not registered")
 	ErrInvalidCertOrKeyType  = errors.New("invalid cert or key type, must be string or []byte")
+	ErrInvalidRedirectCode   = errors.New("invalid redirect status code")
+	ErrRedirectLoop          = errors.New("redirect loop detected")
+	ErrRouteNotFound         = errors.New("route not found")
+	ErrRouteConflict         = errors.New("route conflict")
+	ErrInvalidBindTarget     = errors.New("bind target must be a pointer to struct")
 
 	ErrServerClosed = errors.New("gemini: Server closed")
 
@@ -181,13 +234,14 @@ func DefaultGeminiErrorHandler(err error, c Context) {
 	code := he.Code
 	message := he.Message
 
-	debugPrintf("gemini: handling error: %s", err)
+	log := c.Gig().logger()
+	log.Debug("handling error", "path", c.URL().Path, "error", err)
 
 	// Send response
 	if !c.Response().Committed {
 		err = c.NoContent(code, message)
 		if err != nil {
-			debugPrintf("gemini: could not handle error: %s", err)
+			log.Error("could not handle error", "path", c.URL().Path, "error", err)
 		}
 	}
 }
@@ -272,11 +326,16 @@ func (common) static(prefix, root string, get func(string, HandlerFunc, ...Middl
 		return c.File(name)
 	}
 
+	var r *Route
 	if prefix == "/" {
-		return get(prefix+"*", h)
+		r = get(prefix+"*", h)
+	} else {
+		r = get(prefix+"/*", h)
 	}
 
-	return get(prefix+"/*", h)
+	r.root = root
+
+	return r
 }
 
 func (common) file(path, file string, get func(string, HandlerFunc, ...MiddlewareFunc) *Route,
@@ -292,9 +351,13 @@ func (g *Gig) File(path, file string, m ...MiddlewareFunc) *Route {
 }
 
 func (g *Gig) add(path string, handler HandlerFunc, middleware ...MiddlewareFunc) *Route {
+	return g.addTo(g.router, path, handler, middleware...)
+}
+
+func (g *Gig) addTo(router *router, path string, handler HandlerFunc, middleware ...MiddlewareFunc) *Route {
 	name := handlerName(handler)
 
-	g.router.add(path, func(c Context) error {
+	router.add(path, func(c Context) error {
 		h := handler
 		// Chain middleware
 		for i := len(middleware) - 1; i >= 0; i-- {
@@ -304,21 +367,121 @@ func (g *Gig) add(path string, handler HandlerFunc, middleware ...MiddlewareFunc
 	})
 
 	r := &Route{
-		Path: path,
-		Name: name,
+		Path:   path,
+		Name:   name,
+		Host:   router.host,
+		router: router,
 	}
 
-	g.router.routes[path] = r
+	router.addRoute(r)
 
 	return r
 }
 
 // Group creates a new router group with prefix and optional group-level middleware.
 func (g *Gig) Group(prefix string, m ...MiddlewareFunc) (gg *Group) {
-	gg = &Group{prefix: prefix, gig: g}
+	return g.group(g.router, prefix, m...)
+}
+
+func (g *Gig) group(router *router, prefix string, m ...MiddlewareFunc) *Group {
+	gg := &Group{prefix: prefix, gig: g, router: router}
 	gg.Use(m...)
 
-	return
+	router.groups = append(router.groups, gg)
+
+	return gg
+}
+
+// Host creates a new router group whose routes only match requests for host,
+// with optional group-level middleware. Host may contain "*" to match any
+// label, such as "*.example.org", or a named label, such as ":sub.example.org",
+// available using `Context#Param()`. Exact hosts take precedence, otherwise
+// hosts are matched in the order they were added. Requests for other hosts
+// are routed to routes added directly to Gig.
+func (g *Gig) Host(host string, m ...MiddlewareFunc) *Group {
+	host = strings.ToLower(host)
+
+	for _, r := range g.hosts {
+		if r.host == host {
+			return g.group(r, "", m...)
+		}
+	}
+
+	r := newRouter(g)
+	r.host = host
+	g.hosts = append(g.hosts, r)
+
+	return g.group(r, "", m...)
+}
+
+// findRouter returns router for request host, loading host params into context.
+func (g *Gig) findRouter(c Context) *router {
+	if len(g.hosts) == 0 {
+		return g.router
+	}
+
+	ctx := c.(*context)
+	host := strings.ToLower(ctx.u.Hostname())
+
+	for _, r := range g.hosts {
+		if r.host == host {
+			return r
+		}
+	}
+
+	for _, r := range g.hosts {
+		if names, values, ok := matchHost(r.host, host); ok {
+			ctx.hnames, ctx.hvalues = names, values
+			return r
+		}
+	}
+
+	return g.router
+}
+
+// Mount dispatches requests with path prefix to an independent Gig instance.
+// The prefix is stripped from request URL, and sub-app's own middleware,
+// error handler and renderer are used. URLs generated by sub-app's `Reverse`
+// include the prefix. A Gig instance can be mounted only once.
+func (g *Gig) Mount(prefix string, sub *Gig) {
+	if sub == g {
+		panic("gig: cannot mount Gig instance onto itself")
+	}
+
+	if sub.parent != nil {
+		panic("gig: Gig instance is already mounted")
+	}
+
+	prefix = strings.TrimSuffix(prefix, "/")
+	sub.parent = g
+	sub.prefix = prefix
+
+	h := func(c Context) error {
+		u := *c.URL()
+		u.Path = stripPrefix(u.Path, prefix)
+
+		if u.RawPath != "" {
+			u.RawPath = stripPrefix(u.RawPath, prefix)
+		}
+
+		sub.handoff(c.(*context), &u)
+
+		return nil
+	}
+
+	g.Handle(prefix, h).mount = true
+	g.Handle(prefix+"/*", h).mount = true
+}
+
+// mountPath returns path prefix under which Gig is mounted.
+func (g *Gig) mountPath() string {
+	var p string
+
+	for ; g.parent != nil; g = g.parent {
+		p = g.prefix + p
+	}
+
+	return p
 }
 
 // URL generates a URL from handler.
@@ -328,55 +491,180 @@ func (g *Gig) URL(handler HandlerFunc, params ...interface{}) string {
 }
 
 // Reverse generates an URL from route name and provided parameters.
+// Parameters are either positional values, or a single map[string]string
+// (or map[string]interface{}) keyed by parameter name, with "*" used for
+// the wildcard. Values are escaped, missing ones are left as is.
+//
+// Routes named with `Route#SetName()` take precedence, otherwise the first
+// registered route with matching handler name is used.
 func (g *Gig) Reverse(name string, params ...interface{}) string {
-	uri := new(bytes.Buffer)
-	ln := len(params)
-	n := 0
-
-	for _, r := range g.router.routes {
-		if r.Name == name {
-			for i, l := 0, len(r.Path); i < l; i++ {
-				if r.Path[i] == ':' && n < ln {
-					for ; i < l && r.Path[i] != '/'; i++ {
-					}
-					uri.WriteString(fmt.Sprintf("%v", params[n]))
-					n++
-				}
+	r := g.router.route(name)
 
-				if i < l {
-					uri.WriteByte(r.Path[i])
-				}
-			}
+	for i := 0; r == nil && i < len(g.hosts); i++ {
+		r = g.hosts[i].route(name)
+	}
 
-			break
-		}
+	if r == nil {
+		return ""
 	}
 
-	return uri.String()
+	return g.mountPath() + r.reverse(params...)
+}
+
+// Validate returns an error wrapping ErrRouteConflict if any route was
+// registered over another with the same path or the same path with different
+// param names, such as "/:a" and "/:b". It is meant to be called before Run.
+func (g *Gig) Validate() error {
+	conflicts := g.router.conflicts
+
+	for _, r := range g.hosts {
+		conflicts = append(conflicts, r.conflicts...)
+	}
+
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrRouteConflict, strings.Join(conflicts, "; "))
 }
 
-// Routes returns the registered routes.
+// Routes returns the registered routes sorted by host and path.
 func (g *Gig) Routes() []*Route {
-	routes := make([]*Route, 0, len(g.router.routes))
-	for _, v := range g.router.routes {
-		routes = append(routes, v)
+	routes := make([]*Route, len(g.router.routes))
+	copy(routes, g.router.routes)
+
+	for _, r := range g.hosts {
+		routes = append(routes, r.routes...)
 	}
 
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Host != routes[j].Host {
+			return routes[i].Host < routes[j].Host
+		}
+
+		return routes[i].Path < routes[j].Path
+	})
+
 	return routes
 }
 
+// SetName sets an explicit route name to be used with `Gig#Reverse()`.
+// It panics if the name is already used by another route.
+func (r *Route) SetName(name string) *Route {
+	if other, ok := r.router.names[name]; ok && other != r {
+		panic(fmt.Sprintf("gig: route name %q is already used by %s", name, other.Path))
+	}
+
+	if r.router.names[r.Name] == r {
+		delete(r.router.names, r.Name)
+	}
+
+	r.Name = name
+	r.router.names[name] = r
+
+	return r
+}
+
+// SetTitle sets route title used in listings.
+func (r *Route) SetTitle(title string) *Route {
+	r.Title = title
+	return r
+}
+
+// SetDescription sets route description used in listings.
+func (r *Route) SetDescription(description string) *Route {
+	r.Description = description
+	return r
+}
+
+// SetHidden excludes route from listings.
+func (r *Route) SetHidden(hidden bool) *Route {
+	r.Hidden = hidden
+	return r
+}
+
+// matchHost reports whether route is reachable for requests to host.
+func (r *Route) matchHost(host string) bool {
+	if r.Host == "" {
+		return true
+	}
+
+	_, _, ok := matchHost(r.Host, host)
+
+	return ok
+}
+
+// IsStatic returns true if route has no parameters.
+func (r *Route) IsStatic() bool {
+	return !strings.ContainsAny(r.Path, ":*")
+}
+
+func (r *Route) reverse(params ...interface{}) string {
+	var (
+		uri   = new(bytes.Buffer)
+		named map[string]string
+		path  = r.Path
+		n     = 0
+	)
+
+	if len(params) == 1 {
+		switch m := params[0].(type) {
+		case map[string]string:
+			named = m
+		case map[string]interface{}:
+			named = make(map[string]string, len(m))
+			for k, v := range m {
+				named[k] = fmt.Sprintf("%v", v)
+			}
+		}
+	}
+
+	value := func(name string) (string, bool) {
+		if named != nil {
+			v, ok := named[name]
+			return v, ok
+		}
+
+		if n < len(params) {
+			n++
+			return fmt.Sprintf("%v", params[n-1]), true
+		}
+
+		return "", false
+	}
+
+	for i, l := 0, len(path); i < l; i++ {
+		switch path[i] {
+		case ':':
+			j := i
+			i = paramEnd(path, i) - 1
+			name, _ := splitParam(path[j+1 : i+1])
+
+			if v, ok := value(name); ok {
+				uri.WriteString(url.PathEscape(v))
+			} else {
+				uri.WriteString(path[j : i+1])
+			}
+		case '*':
+			if v, ok := value("*"); ok {
+				uri.WriteString(escapeWildcard(v))
+			} else {
+				uri.WriteByte('*')
+			}
+		default:
+			uri.WriteByte(path[i])
+		}
+	}
+
+	return uri.String()
+}
+
 // ServeGemini serves Gemini request.
 func (g *Gig) ServeGemini(c Context) {
 	if c.Gig() != g {
 		// Acquire context from correct Gig and use it instead.
-		orig := c.(*context)
-
-		ctx := g.ctxpool.Get().(*context)
-		defer g.ctxpool.Put(ctx)
-
-		ctx.reset(orig.conn, orig.u, orig.requestURI, orig.TLS)
-
-		c = ctx
+		g.handoff(c.(*context), c.URL())
+		return
 	}
 
 	var h HandlerFunc
@@ -384,12 +672,12 @@ func (g *Gig) ServeGemini(c Context) {
 	URL := c.URL()
 
 	if g.premiddleware == nil {
-		g.router.find(getPath(URL), c)
+		g.findRouter(c).find(getPath(URL), c)
 		h = c.Handler()
 		h = applyMiddleware(h, g.middleware...)
 	} else {
 		h = func(c Context) error {
-			g.router.find(getPath(URL), c)
+			g.findRouter(c).find(getPath(URL), c)
 			h := c.Handler()
 			h = applyMiddleware(h, g.middleware...)
 			return h(c)
@@ -399,8 +687,41 @@ func (g *Gig) ServeGemini(c Context) {
 
 	// Execute chain
 	if err := h(c); err != nil {
-		g.GeminiErrorHandler(err, c)
+		c.Error(err)
+	}
+}
+
+// handoff serves request of a context acquired from another Gig using URL u.
+func (g *Gig) handoff(orig *context, u *url.URL) {
+	ctx := g.ctxpool.Get().(*context)
+	defer g.ctxpool.Put(ctx)
+
+	// Share store, so that values set by the mounted Gig, such as request
+	// ID, are seen by middleware of the other one
+	orig.lock.Lock()
+	if orig.store == nil {
+		orig.store = make(storeMap)
 	}
+	orig.lock.Unlock()
+
+	ctx.reset(orig.conn, u, orig.requestURI, orig.TLS)
+	ctx.outer = orig.outerURL()
+	ctx.store = orig.store
+	ctx.response.onHeader = orig.response.onHeader
+
+	g.ServeGemini(ctx)
+
+	// Make response visible to middleware of the other Gig, e.g. Logger.
+	*orig.response = *ctx.response
+}
+
+// root returns Gig that g is mounted onto, directly or not, or g.
+func (g *Gig) root() *Gig {
+	for g.parent != nil {
+		g = g.parent
+	}
+
+	return g
 }
 
 // Run starts a Gemini server.
@@ -477,7 +798,7 @@ func (g *Gig) startTLS(address string) error {
 	defer g.listener.Close()
 
 	if !g.HidePort {
-		debugPrintf("⇨ gemini server started on %s\n", g.listener.Addr())
+		g.logger().Info("gemini server started", "addr", g.listener.Addr())
 	}
 
 	return g.serve()
@@ -506,7 +827,7 @@ func (g *Gig) serve() error {
 					tempDelay = max
 				}
 
-				debugPrintf("gemini: Accept error: %v; retrying in %v", err, tempDelay)
+				g.logger().Error("accept error", "error", err, "retry_in", tempDelay)
 				time.Sleep(tempDelay)
 
 				continue
@@ -517,7 +838,7 @@ func (g *Gig) serve() error {
 
 		tc, ok := conn.(*tls.Conn)
 		if !ok {
-			debugPrintf("gemini: non-tls connection")
+			g.logger().Warn("non-TLS connection", "remote_ip", conn.RemoteAddr())
 			continue
 		}
 
@@ -534,10 +855,40 @@ type tlsconn interface {
 func (g *Gig) handleRequest(conn tlsconn) {
 	defer conn.Close()
 
+	var (
+		log     = g.logger()
+		metrics = g.metrics()
+		start   = time.Now()
+	)
+
+	metrics.connOpened()
+	defer metrics.connClosed()
+
+	if g.OnAccept != nil {
+		g.OnAccept(conn)
+	}
+
 	if d := g.ReadTimeout; d != 0 {
 		err := conn.SetReadDeadline(time.Now().Add(d))
 		if err != nil {
-			debugPrintf("gemini: could not set socket read timeout: %s", err)
+			log.Warn("could not set socket read timeout", "error", err)
+		}
+	}
+
+	// Handshake explicitly, so that its failures are not reported as read
+	// errors
+	if hc, ok := conn.(interface{ Handshake() error }); ok {
+		err := hc.Handshake()
+
+		if g.OnHandshake != nil {
+			g.OnHandshake(conn, time.Since(start), err)
+		}
+
+		if err != nil {
+			log.Warn("TLS handshake failed", "remote_ip", conn.RemoteAddr(), "error", err)
+			metrics.handshakeFailed()
+
+			return
 		}
 	}
 
@@ -549,18 +900,20 @@ func (g *Gig) handleRequest(conn tlsconn) {
 	request, overflow, err := reader.ReadLine()
 
 	if overflow {
-		debugPrintf("gemini: request overflow")
+		log.Warn("request too long", "remote_ip", conn.RemoteAddr())
+		metrics.requestError("overflow")
 
 		_, _ = conn.Write(responseRequestTooLong)
 
 		return
 	} else if err != nil {
 		if err == io.EOF {
-			debugPrintf("gemini: EOF reading from client, read %d bytes", len(request))
+			log.Debug("EOF reading request", "remote_ip", conn.RemoteAddr(), "bytes", len(request))
 			return
 		}
 
-		debugPrintf("gemini: unknown error reading request header: %s", err)
+		log.Warn("could not read request", "remote_ip", conn.RemoteAddr(), "error", err)
+		metrics.requestError("read")
 
 		_, _ = conn.Write(responseUnknownError)
 
@@ -571,7 +924,8 @@ func (g *Gig) handleRequest(conn tlsconn) {
 	URL, err := url.Parse(header)
 
 	if err != nil {
-		debugPrintf("gemini: invalid request url: %s", err)
+		log.Warn("invalid request URL", "remote_ip", conn.RemoteAddr(), "error", err)
+		metrics.requestError("url")
 
 		_, _ = conn.Write(responseBadURL)
 
@@ -583,7 +937,8 @@ func (g *Gig) handleRequest(conn tlsconn) {
 	}
 
 	if URL.Scheme != "gemini" {
-		debugPrintf("gemini: non-gemini scheme: %s", header)
+		log.Warn("non-gemini scheme", "remote_ip", conn.RemoteAddr(), "url", header)
+		metrics.requestError("scheme")
 
 		_, _ = conn.Write(responseBadSchema)
 
@@ -593,7 +948,7 @@ func (g *Gig) handleRequest(conn tlsconn) {
 	if d := g.WriteTimeout; d != 0 {
 		err := conn.SetWriteDeadline(time.Now().Add(d))
 		if err != nil {
-			debugPrintf("gemini: could not set socket write timeout: %s", err)
+			log.Warn("could not set socket write timeout", "error", err)
 		}
 	}
 
@@ -603,8 +958,22 @@ func (g *Gig) handleRequest(conn tlsconn) {
 	c := g.ctxpool.Get().(*context)
 	c.reset(conn, URL, header, &tlsState)
 
+	if g.OnRequestParsed != nil {
+		g.OnRequestParsed(c, time.Since(start))
+	}
+
+	if g.OnResponseHeader != nil {
+		c.response.onHeader = func(status Status, meta string) {
+			g.OnResponseHeader(c, status, meta, time.Since(start))
+		}
+	}
+
 	g.ServeGemini(c)
 
+	if g.OnResponseDone != nil {
+		g.OnResponseDone(c, time.Since(start))
+	}
+
 	// Release context
 	g.ctxpool.Put(c)
 }
@@ -650,6 +1019,26 @@ func getPath(u *url.URL) string {
 	return path
 }
 
+// stripPrefix removes prefix from path, keeping it absolute.
+func stripPrefix(path, prefix string) string {
+	path = strings.TrimPrefix(path, prefix)
+	if path == "" || path[0] != '/' {
+		path = "/" + path
+	}
+
+	return path
+}
+
+// escapeWildcard escapes every segment of a wildcard value, keeping slashes.
+func escapeWildcard(v string) string {
+	segments := strings.Split(v, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+
+	return strings.Join(segments, "/")
+}
+
 func handlerName(h HandlerFunc) string {
 	t := reflect.ValueOf(h).Type()
 	if t.Kind() == reflect.Func {
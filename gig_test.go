@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/tls"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net"
 	"strings"
@@ -189,15 +190,62 @@ func TestGigURL(t *testing.T) {
 	is.Equal("/group/users/1/files/1", g.URL(getFile, "1", "1"))
 }
 
+func TestGigReverse(t *testing.T) {
+	is := is.New(t)
+
+	g := New()
+	h := func(Context) error { return nil }
+
+	g.Handle("/plant/water", h).SetName("plant-water")
+	g.Handle("/users/:name", h).SetName("user")
+	g.Handle("/users/:name/files/:fid", h).SetName("user-file")
+	g.Handle("/static/*", h).SetName("static")
+	g.Handle("/other/:name", h)
+	g.Handle("/another/:name", h)
+
+	is.Equal("/plant/water", g.Reverse("plant-water"))
+	is.Equal("/users/jon", g.Reverse("user", "jon"))
+	is.Equal("/users/jon%2Fsnow", g.Reverse("user", "jon/snow"))
+	is.Equal("/users/:name", g.Reverse("user"))
+	is.Equal("/users/jon/files/7", g.Reverse("user-file", map[string]string{"name": "jon", "fid": "7"}))
+	is.Equal("/users/jon/files/7", g.Reverse("user-file", map[string]interface{}{"name": "jon", "fid": 7}))
+	is.Equal("/users/:name/files/7", g.Reverse("user-file", map[string]string{"fid": "7"}))
+	is.Equal("/static/a%20b/c.gmi", g.Reverse("static", "a b/c.gmi"))
+	is.Equal("/static/x", g.Reverse("static", map[string]string{"*": "x"}))
+	is.Equal("/static/*", g.Reverse("static"))
+	is.Equal("", g.Reverse("missing"))
+
+	// Handler names are resolved in registration order
+	for i := 0; i < 10; i++ {
+		is.Equal("/other/x", g.URL(h, "x"))
+	}
+
+	// Renaming frees the old name
+	r := g.Handle("/renamed", h).SetName("old")
+	r.SetName("new")
+	is.Equal("", g.Reverse("old"))
+	is.Equal("/renamed", g.Reverse("new"))
+
+	// Re-registering a path replaces its route
+	g.Handle("/renamed", h)
+	is.Equal("", g.Reverse("new"))
+
+	defer func() {
+		is.True(recover() != nil)
+	}()
+
+	g.Handle("/duplicate", h).SetName("user")
+}
+
 func TestGigRoutes(t *testing.T) {
 	is := is.New(t)
 
 	g := New()
 	routes := []*Route{
-		{"/users/:user/events", ""},
-		{"/users/:user/events/public", ""},
-		{"/repos/:owner/:repo/git/refs", ""},
-		{"/repos/:owner/:repo/git/tags", ""},
+		{Path: "/users/:user/events"},
+		{Path: "/users/:user/events/public"},
+		{Path: "/repos/:owner/:repo/git/refs"},
+		{Path: "/repos/:owner/:repo/git/tags"},
 	}
 
 	for _, r := range routes {
@@ -208,6 +256,16 @@ func TestGigRoutes(t *testing.T) {
 
 	is.Equal(len(routes), len(g.Routes()))
 
+	// Registering same path again replaces route
+	g.Handle(routes[0].Path, func(c Context) error {
+		return c.Text("OK")
+	})
+	is.Equal(len(routes), len(g.Routes()))
+
+	// Sorted by path
+	is.Equal("/repos/:owner/:repo/git/refs", g.Routes()[0].Path)
+	is.Equal("/users/:user/events/public", g.Routes()[3].Path)
+
 	for _, r := range g.Routes() {
 		found := false
 
@@ -518,3 +576,161 @@ func BenchmarkGig(b *testing.B) {
 		g.handleRequest(&conn)
 	}
 }
+
+func TestGigMount(t *testing.T) {
+	is := is.New(t)
+
+	var (
+		g   = New()
+		sub = New()
+		buf = new(bytes.Buffer)
+	)
+
+	g.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			c.Set("user", "jon")
+			err := next(c)
+			buf.WriteString(fmt.Sprintf("parent:%d ", c.Response().Status))
+
+			return err
+		}
+	})
+	sub.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			buf.WriteString("sub ")
+			return next(c)
+		}
+	})
+	sub.GeminiErrorHandler = func(err error, c Context) {
+		_ = c.NoContent(StatusNotFound, "Nothing in guestbook")
+	}
+
+	sub.Handle("/", func(c Context) error {
+		return c.Gemini("=> %s Sign", c.Gig().Reverse("sign"))
+	})
+	sub.Handle("/sign/:name", func(c Context) error {
+		return c.Text("%s %s %s", c.URL().Path, c.Param("name"), c.Get("user"))
+	}).SetName("sign")
+	sub.Static("/images", "_fixture/images")
+	g.Mount("/guestbook/", sub)
+
+	is.Equal("20 text/gemini\r\n=> /guestbook/sign/:name Sign", request("/guestbook", g))
+	is.Equal("20 text/gemini\r\n=> /guestbook/sign/:name Sign", request("/guestbook/", g))
+	is.Equal("20 text/plain\r\n/sign/jon jon jon", request("/guestbook/sign/jon", g))
+	is.Equal("51 Nothing in guestbook\r\n", request("/guestbook/missing", g))
+	is.Equal("sub parent:20 sub parent:20 sub parent:20 sub parent:51 ", buf.String())
+	is.Equal("51 Not Found\r\n", request("/guestbookx", g))
+	is.Equal("20 text/gemini\r\n# Listing /guestbook/images/\n\n=> /guestbook/images/walle.png walle.png [ 219.9kB ]\n",
+		request("/guestbook/images/", g))
+
+	// Nested
+	app := New()
+	app.Handle("/", func(c Context) error {
+		return c.Text("%s", c.Gig().Reverse("page"))
+	}).SetName("page")
+	sub.Mount("/app", app)
+	is.Equal("20 text/plain\r\n/guestbook/app/", request("/guestbook/app", g))
+
+	defer func() {
+		is.True(recover() != nil)
+	}()
+
+	New().Mount("/again", sub)
+}
+
+func TestGigMount_Redirect(t *testing.T) {
+	is := is.New(t)
+
+	var (
+		g   = New()
+		gb  = New()
+		app = New()
+	)
+
+	gb.Handle("/a/b", func(c Context) error {
+		return c.Redirect(StatusRedirectTemporary, "c")
+	})
+	gb.Handle("/back", func(c Context) error {
+		return c.Redirect(StatusRedirectTemporary, "/")
+	})
+	app.Pre(Normalize())
+	app.Handle("/a/b", func(c Context) error {
+		return c.Text("ok")
+	})
+	g.Mount("/gb", gb)
+	gb.Mount("/app", app)
+
+	is.Equal("30 gemini://h/gb/a/c\r\n", request("gemini://h/gb/a/b", g))
+	is.Equal("30 gemini://h/\r\n", request("gemini://h/gb/back", g))
+	is.Equal("31 gemini://h/gb/app/a/b\r\n", request("gemini://h/gb/app/a//b", g))
+	is.Equal("20 text/plain\r\nok", request("gemini://h/gb/app/a/b", g))
+}
+
+func TestGigMount_Store(t *testing.T) {
+	is := is.New(t)
+
+	var (
+		g   = New()
+		sub = New()
+		id  interface{}
+	)
+
+	g.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			err := next(c)
+			id = c.Get("request_id")
+
+			return err
+		}
+	})
+	sub.Use(RequestID())
+	sub.Handle("/", func(c Context) error {
+		return c.Text("ok")
+	})
+	g.Mount("/sub", sub)
+
+	// Pooled contexts start with no store
+	c, conn := g.NewFakeContext("/sub", nil)
+	c.(*context).store = nil
+	g.ServeGemini(c)
+
+	is.Equal("20 text/plain\r\nok", conn.Written)
+	is.True(id != nil) // request ID set by mounted Gig is visible
+}
+
+func TestGigHost(t *testing.T) {
+	is := is.New(t)
+
+	g := New()
+	g.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			c.Set("mw", "root")
+			return next(c)
+		}
+	})
+
+	h := func(c Context) error {
+		return c.Text("%s %s %s", c.Path(), c.Param("sub"), c.Get("mw"))
+	}
+
+	g.Handle("/", h)
+	g.Host("wiki.example.org").Handle("/", h).SetName("wiki")
+	g.Host(":sub.example.org").Handle("/page/:id", h)
+	g.Host("*.example.org").Handle("/:id", h)
+	g.Host("Wiki.Example.org").Handle("/about", h)
+
+	is.Equal("20 text/plain\r\n/  root", request("gemini://example.org/", g))
+	is.Equal("20 text/plain\r\n/  root", request("gemini://wiki.example.org/", g))
+	is.Equal("20 text/plain\r\n/about  root", request("gemini://WIKI.example.org:1965/about", g))
+	is.Equal("51 Not Found\r\n", request("gemini://wiki.example.org/page/1", g))
+	is.Equal("20 text/plain\r\n/page/:id blog root", request("gemini://blog.example.org/page/1", g))
+	is.Equal("51 Not Found\r\n", request("gemini://blog.example.org/1", g))
+	is.Equal("51 Not Found\r\n", request("gemini://a.blog.example.org/page/1", g))
+	is.Equal("/", g.Reverse("wiki"))
+
+	routes := g.Routes()
+	is.Equal(5, len(routes))
+	is.Equal("", routes[0].Host)
+	is.Equal("*.example.org", routes[1].Host)
+	is.Equal("wiki.example.org", routes[4].Host)
+}
@@ -5,4 +5,5 @@ go 1.14
 require (
 	github.com/matryer/is v1.3.0
 	github.com/valyala/fasttemplate v1.1.0
+	golang.org/x/crypto v0.0.0-20201016220609-9e8e0b390897
 )
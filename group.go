@@ -1,27 +1,41 @@
 package gig
 
+import (
+	"strings"
+)
+
 type (
 	// Group is a set of sub-routes for a specified route. It can be used for inner
 	// routes that share a common middleware or functionality that should be separate
 	// from the parent gig instance while still inheriting from it.
 	Group struct {
 		common
-		prefix     string
-		middleware []MiddlewareFunc
-		gig        *Gig
+		prefix       string
+		middleware   []MiddlewareFunc
+		gig          *Gig
+		router       *router
+		parent       *Group
+		errorHandler GeminiErrorHandler
+		notFound     HandlerFunc
 	}
 )
 
-// Use implements `Gig#Use()` for sub-routes within the Group.
+// Use implements `Gig#Use()` for sub-routes within the Group. Group middleware
+// is also run for requests within the group that have no matching route.
 func (g *Group) Use(middleware ...MiddlewareFunc) {
 	g.middleware = append(g.middleware, middleware...)
-	if len(g.middleware) == 0 {
-		return
-	}
-	// Allow all requests to reach the group as they might get dropped if router
-	// doesn't find a match, making none of the group middleware process.
-	g.Handle("", NotFoundHandler)
-	g.Handle("/*", NotFoundHandler)
+}
+
+// SetErrorHandler sets error handler used for routes within the Group and its
+// sub-groups instead of `Gig#GeminiErrorHandler`.
+func (g *Group) SetErrorHandler(h GeminiErrorHandler) {
+	g.errorHandler = h
+}
+
+// SetNotFound sets handler for requests within the Group and its sub-groups
+// that have no matching route, instead of `NotFoundHandler`.
+func (g *Group) SetNotFound(h HandlerFunc) {
+	g.notFound = h
 }
 
 // Handle implements `Gig#Handle()` for sub-routes within the Group.
@@ -35,26 +49,75 @@ func (g *Group) Group(prefix string, middleware ...MiddlewareFunc) *Group {
 	m = append(m, g.middleware...)
 	m = append(m, middleware...)
 
-	return g.gig.Group(g.prefix+prefix, m...)
+	sg := g.gig.group(g.router, g.prefix+prefix, m...)
+	sg.parent = g
+
+	return sg
 }
 
 // Static implements `Gig#Static()` for sub-routes within the Group.
-func (g *Group) Static(prefix, root string) {
-	g.static(prefix, root, g.Handle)
+func (g *Group) Static(prefix, root string) *Route {
+	return g.static(prefix, root, g.Handle)
 }
 
 // File implements `Gig#File()` for sub-routes within the Group.
-func (g *Group) File(path, file string) {
-	g.file(path, file, g.Handle)
+func (g *Group) File(path, file string) *Route {
+	return g.file(path, file, g.Handle)
 }
 
 func (g *Group) add(path string, handler HandlerFunc, middleware ...MiddlewareFunc) *Route {
 	// Combine into a new slice to avoid accidentally passing the same slice for
 	// multiple routes, which would lead to later add() calls overwriting the
 	// middleware from earlier calls.
-	m := make([]MiddlewareFunc, 0, len(g.middleware)+len(middleware))
+	m := make([]MiddlewareFunc, 0, len(g.middleware)+len(middleware)+1)
+	m = append(m, g.scope)
 	m = append(m, g.middleware...)
 	m = append(m, middleware...)
 
-	return g.gig.add(g.prefix+path, handler, m...)
+	return g.gig.addTo(g.router, g.prefix+path, handler, m...)
+}
+
+// scope marks context as handled within the Group.
+func (g *Group) scope(next HandlerFunc) HandlerFunc {
+	return func(c Context) error {
+		c.(*context).group = g
+		return next(c)
+	}
+}
+
+// match reports whether path is within the Group.
+func (g *Group) match(path string) bool {
+	prefix := strings.TrimSuffix(g.prefix, "/")
+
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// notFoundHandler returns handler for unmatched requests within the Group,
+// wrapped in group middleware.
+func (g *Group) notFoundHandler() HandlerFunc {
+	h := NotFoundHandler
+
+	for p := g; p != nil; p = p.parent {
+		if p.notFound != nil {
+			h = p.notFound
+			break
+		}
+	}
+
+	m := make([]MiddlewareFunc, 0, len(g.middleware)+1)
+	m = append(m, g.scope)
+	m = append(m, g.middleware...)
+
+	return applyMiddleware(h, m...)
+}
+
+// geminiErrorHandler returns error handler of the Group or its parents.
+func (g *Group) geminiErrorHandler() GeminiErrorHandler {
+	for p := g; p != nil; p = p.parent {
+		if p.errorHandler != nil {
+			return p.errorHandler
+		}
+	}
+
+	return g.gig.GeminiErrorHandler
 }
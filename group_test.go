@@ -1,6 +1,7 @@
 package gig
 
 import (
+	"fmt"
 	"io/ioutil"
 	"strings"
 	"testing"
@@ -150,5 +151,52 @@ func TestGroupRouteMiddlewareWithMatchAny(t *testing.T) {
 	b = request("/other", gig)
 	is.Equal("20 text/plain\r\n/*", b)
 	b = request("/", gig)
-	is.Equal("20 text/plain\r\n", b)
+	is.Equal("20 text/plain\r\n/*", b)
+}
+
+func TestGroupErrorHandlerAndNotFound(t *testing.T) {
+	is := is.New(t)
+
+	g := New()
+	g.Handle("/", func(c Context) error {
+		return ErrGone
+	})
+
+	api := g.Group("/api")
+	api.SetErrorHandler(func(err error, c Context) {
+		_ = c.NoContent(StatusPermanentFailure, "api: %s", err.(*GeminiError).Message)
+	})
+	api.Handle("/gone", func(c Context) error {
+		return ErrGone
+	})
+
+	v1 := api.Group("/v1", func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			c.Set("v1", true)
+			return next(c)
+		}
+	})
+	v1.SetNotFound(func(c Context) error {
+		return NewErrorFrom(ErrNotFound, fmt.Sprintf("no such endpoint, v1=%v", c.Get("v1")))
+	})
+	v1.Handle("/gone", func(c Context) error {
+		c.Error(ErrGone)
+		return nil
+	})
+
+	blog := g.Group("/blog/")
+	blog.SetNotFound(func(c Context) error {
+		return c.Gemini("# No such post")
+	})
+
+	is.Equal("52 Gone\r\n", request("/", g))
+	is.Equal("51 Not Found\r\n", request("/missing", g))
+	is.Equal("50 api: Gone\r\n", request("/api/gone", g))
+	is.Equal("50 api: Not Found\r\n", request("/api/missing", g))
+	is.Equal("50 api: Not Found\r\n", request("/api", g))
+	is.Equal("51 Not Found\r\n", request("/apix", g))
+	is.Equal("50 api: Gone\r\n", request("/api/v1/gone", g))
+	is.Equal("50 api: no such endpoint, v1=true\r\n", request("/api/v1/missing", g))
+	is.Equal("20 text/gemini\r\n# No such post", request("/blog/2020/missing", g))
+	is.Equal("20 text/gemini\r\n# No such post", request("/blog", g))
 }
@@ -0,0 +1,504 @@
+package gig
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type (
+	// Identity is a user record that one or more client certificates are
+	// linked to.
+	Identity struct {
+		// ID uniquely identifies identity.
+		ID string `json:"id"`
+
+		// Name is subject common name of the first certificate seen, unless
+		// changed by IdentityAuthConfig.OnFirstSeen.
+		Name string `json:"name"`
+
+		// Fingerprints of certificates linked to identity.
+		Fingerprints []string `json:"fingerprints"`
+
+		// Data holds application specific values.
+		Data map[string]string `json:"data,omitempty"`
+
+		// FirstSeen is the time identity was registered.
+		FirstSeen time.Time `json:"first_seen"`
+	}
+
+	// IdentityStore persists identities and maps certificate fingerprints to
+	// them. Implementations must be safe for concurrent use.
+	IdentityStore interface {
+		// Lookup returns identity linked to fingerprint, or nil if there is
+		// none.
+		Lookup(fingerprint string) (*Identity, error)
+
+		// Get returns identity by ID, or nil if there is none.
+		Get(id string) (*Identity, error)
+
+		// Save creates or updates identity and links its fingerprints to it.
+		Save(identity *Identity) error
+
+		// Register saves new identity, unless one of its fingerprints is
+		// already linked to another identity. Registered identity is
+		// returned, or the existing one if it won. Check and save must be
+		// atomic, so that concurrent first requests with the same
+		// certificate end up with a single identity.
+		Register(identity *Identity) (*Identity, error)
+
+		// Link links fingerprint to identity with id, unlinking it from any
+		// other identity.
+		Link(id, fingerprint string) error
+
+		// Unlink removes fingerprint from its identity. Identities without
+		// fingerprints are removed.
+		Unlink(fingerprint string) error
+	}
+
+	// IdentityAuthConfig defines the config for IdentityAuth middleware.
+	IdentityAuthConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Store persists identities.
+		// Required.
+		Store IdentityStore
+
+		// Fingerprint returns fingerprint of client certificate. If set,
+		// FingerprintAlgorithm is not used.
+		// Optional.
+		Fingerprint func(*x509.Certificate) string
+
+		// FingerprintAlgorithm defines how client certificate is
+		// fingerprinted. Use FingerprintPublicKeySHA256 to keep identity when
+		// certificate is re-issued for the same key.
+		// Optional. Default value FingerprintSHA256.
+		FingerprintAlgorithm FingerprintAlgorithm
+
+		// OnFirstSeen is called for certificates that are not linked to any
+		// identity yet, before identity is saved. It may modify identity, or
+		// return an error to reject the request without registering it.
+		// Concurrent first requests with the same certificate may each call
+		// it, but only one identity is registered.
+		// Optional.
+		OnFirstSeen func(identity *Identity, c Context) error
+
+		// Optional makes requests without client certificate pass through
+		// without identity instead of being rejected.
+		// Optional. Default value false.
+		Optional bool
+	}
+
+	// IdentityMemoryStore is an IdentityStore that keeps identities in memory.
+	IdentityMemoryStore struct {
+		mu           sync.RWMutex
+		identities   map[string]*Identity // By ID
+		fingerprints map[string]string    // Fingerprint to ID
+		persist      func() error
+	}
+)
+
+const identityKey = "identity"
+
+var (
+	// DefaultIdentityAuthConfig is the default IdentityAuth middleware config.
+	DefaultIdentityAuthConfig = IdentityAuthConfig{
+		Skipper:              DefaultSkipper,
+		FingerprintAlgorithm: FingerprintSHA256,
+	}
+
+	// ErrIdentityNotFound is returned when linking a certificate to an
+	// identity that does not exist.
+	ErrIdentityNotFound = errors.New("identity not found")
+)
+
+// IdentityAuth returns a middleware that requires a client certificate and
+// loads identity linked to it into context, see `GetIdentity()`. Certificates
+// seen for the first time are registered as new identities (trust on first
+// use).
+func IdentityAuth(store IdentityStore) MiddlewareFunc {
+	c := DefaultIdentityAuthConfig
+	c.Store = store
+
+	return IdentityAuthWithConfig(c)
+}
+
+// IdentityAuthWithConfig returns an IdentityAuth middleware with config.
+// See `IdentityAuth()`.
+func IdentityAuthWithConfig(config IdentityAuthConfig) MiddlewareFunc {
+	// Defaults
+	if config.Store == nil {
+		panic("gig: identity auth middleware requires a store")
+	}
+
+	if config.Skipper == nil {
+		config.Skipper = DefaultIdentityAuthConfig.Skipper
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			cert := c.Certificate()
+			if cert == nil {
+				if config.Optional {
+					return next(c)
+				}
+
+				return ErrClientCertificateRequired
+			}
+
+			id, err := config.identify(cert, c)
+			if err != nil {
+				return err
+			}
+
+			c.Set(identityKey, id)
+
+			return next(c)
+		}
+	}
+}
+
+// fingerprint returns fingerprint of cert as configured.
+func (config *IdentityAuthConfig) fingerprint(cert *x509.Certificate) string {
+	if config.Fingerprint != nil {
+		return config.Fingerprint(cert)
+	}
+
+	return CertFingerprint(cert, config.FingerprintAlgorithm)
+}
+
+// identify returns identity linked to cert, registering it if needed.
+func (config *IdentityAuthConfig) identify(cert *x509.Certificate, c Context) (*Identity, error) {
+	fp := config.fingerprint(cert)
+
+	id, err := config.Store.Lookup(fp)
+	if err != nil || id != nil {
+		return id, err
+	}
+
+	id = &Identity{
+		ID:           randomID(),
+		Name:         cert.Subject.CommonName,
+		Fingerprints: []string{fp},
+		FirstSeen:    time.Now(),
+	}
+
+	if config.OnFirstSeen != nil {
+		if err = config.OnFirstSeen(id, c); err != nil {
+			return nil, err
+		}
+	}
+
+	return config.Store.Register(id)
+}
+
+// GetIdentity returns identity loaded by IdentityAuth middleware, or nil.
+func GetIdentity(c Context) *Identity {
+	id, _ := c.Get(identityKey).(*Identity)
+	return id
+}
+
+// IdentityLinkHandle sets up handlers to link additional certificates to an
+// identity. Visiting path with a linked certificate shows a one-time code,
+// which is valid for 10 minutes. Entering that code at path/code with another
+// certificate links that certificate to the same identity.
+func (g *Gig) IdentityLinkHandle(path string, config IdentityAuthConfig) {
+	var (
+		mu    sync.Mutex
+		codes = map[string]identityLinkCode{}
+	)
+
+	g.Handle(path, func(c Context) error {
+		cert := c.Certificate()
+		if cert == nil {
+			return ErrClientCertificateRequired
+		}
+
+		id, err := config.identify(cert, c)
+		if err != nil {
+			return err
+		}
+
+		code := randomID()[:8]
+
+		mu.Lock()
+		for k, v := range codes {
+			if time.Now().After(v.expires) {
+				delete(codes, k)
+			}
+		}
+		codes[code] = identityLinkCode{id: id.ID, expires: time.Now().Add(10 * time.Minute)}
+		mu.Unlock()
+
+		return c.Gemini("# Link certificate\n\nOpen the link below using another certificate and enter code %s\n\n=> %s/code", code, path)
+	})
+
+	g.Handle(path+"/code", func(c Context) error {
+		cert := c.Certificate()
+		if cert == nil {
+			return ErrClientCertificateRequired
+		}
+
+		code, err := c.QueryString()
+		if err != nil {
+			return NewErrorFrom(ErrBadRequest, "Invalid code")
+		}
+
+		if code == "" {
+			return c.NoContent(StatusInput, "Enter code")
+		}
+
+		mu.Lock()
+		link, ok := codes[code]
+		delete(codes, code)
+		mu.Unlock()
+
+		if !ok || time.Now().After(link.expires) {
+			return c.NoContent(StatusInput, "Invalid code, try again")
+		}
+
+		if err := config.Store.Link(link.id, config.fingerprint(cert)); err != nil {
+			return err
+		}
+
+		return c.Gemini("# Certificate linked")
+	})
+}
+
+type identityLinkCode struct {
+	id      string
+	expires time.Time
+}
+
+// NewIdentityMemoryStore returns an empty IdentityMemoryStore.
+func NewIdentityMemoryStore() *IdentityMemoryStore {
+	return &IdentityMemoryStore{
+		identities:   map[string]*Identity{},
+		fingerprints: map[string]string{},
+	}
+}
+
+// NewIdentityFileStore returns an IdentityStore that keeps identities in
+// memory and writes them to file as JSON after every change. Existing file is
+// loaded first.
+func NewIdentityFileStore(file string) (*IdentityMemoryStore, error) {
+	s := NewIdentityMemoryStore()
+
+	b, err := ioutil.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if len(b) > 0 {
+		var ids []*Identity
+		if err = json.Unmarshal(b, &ids); err != nil {
+			return nil, err
+		}
+
+		for _, id := range ids {
+			s.save(id)
+		}
+	}
+
+	s.persist = func() error {
+		ids := make([]*Identity, 0, len(s.identities))
+		for _, id := range s.identities {
+			ids = append(ids, id)
+		}
+
+		b, err := json.MarshalIndent(ids, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		return writeFileAtomic(file, b)
+	}
+
+	return s, nil
+}
+
+// Lookup implements IdentityStore.
+func (s *IdentityMemoryStore) Lookup(fingerprint string) (*Identity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.identities[s.fingerprints[fingerprint]].clone(), nil
+}
+
+// Get implements IdentityStore.
+func (s *IdentityMemoryStore) Get(id string) (*Identity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.identities[id].clone(), nil
+}
+
+// Save implements IdentityStore.
+func (s *IdentityMemoryStore) Save(identity *Identity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.save(identity.clone())
+
+	return s.flush()
+}
+
+// Register implements IdentityStore.
+func (s *IdentityMemoryStore) Register(identity *Identity) (*Identity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, fp := range identity.Fingerprints {
+		if id, ok := s.fingerprints[fp]; ok {
+			return s.identities[id].clone(), nil
+		}
+	}
+
+	s.save(identity.clone())
+
+	if err := s.flush(); err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+// Link implements IdentityStore.
+func (s *IdentityMemoryStore) Link(id, fingerprint string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	identity := s.identities[id]
+	if identity == nil {
+		return ErrIdentityNotFound
+	}
+
+	if s.fingerprints[fingerprint] == id {
+		return nil
+	}
+
+	s.unlink(fingerprint)
+	identity.Fingerprints = append(identity.Fingerprints, fingerprint)
+	s.fingerprints[fingerprint] = id
+
+	return s.flush()
+}
+
+// Unlink implements IdentityStore.
+func (s *IdentityMemoryStore) Unlink(fingerprint string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.unlink(fingerprint)
+
+	return s.flush()
+}
+
+func (s *IdentityMemoryStore) save(identity *Identity) {
+	if old := s.identities[identity.ID]; old != nil {
+		for _, fp := range old.Fingerprints {
+			delete(s.fingerprints, fp)
+		}
+	}
+
+	s.identities[identity.ID] = identity
+
+	for _, fp := range identity.Fingerprints {
+		if other, ok := s.fingerprints[fp]; ok && other != identity.ID {
+			s.unlink(fp)
+		}
+
+		s.fingerprints[fp] = identity.ID
+	}
+}
+
+func (s *IdentityMemoryStore) unlink(fingerprint string) {
+	id, ok := s.fingerprints[fingerprint]
+	if !ok {
+		return
+	}
+
+	delete(s.fingerprints, fingerprint)
+
+	identity := s.identities[id]
+	for i, fp := range identity.Fingerprints {
+		if fp == fingerprint {
+			identity.Fingerprints = append(identity.Fingerprints[:i:i], identity.Fingerprints[i+1:]...)
+			break
+		}
+	}
+
+	if len(identity.Fingerprints) == 0 {
+		delete(s.identities, id)
+	}
+}
+
+func (s *IdentityMemoryStore) flush() error {
+	if s.persist == nil {
+		return nil
+	}
+
+	return s.persist()
+}
+
+func (id *Identity) clone() *Identity {
+	if id == nil {
+		return nil
+	}
+
+	c := *id
+	c.Fingerprints = append([]string(nil), id.Fingerprints...)
+
+	if id.Data != nil {
+		c.Data = make(map[string]string, len(id.Data))
+		for k, v := range id.Data {
+			c.Data[k] = v
+		}
+	}
+
+	return &c
+}
+
+// writeFileAtomic writes data to a temporary file and renames it to file, so
+// that readers never see partially written content.
+func writeFileAtomic(file string, data []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(file), filepath.Base(file)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+
+		return err
+	}
+
+	if err = f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return os.Rename(f.Name(), file)
+}
+
+func randomID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,191 @@
+package gig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func identityRequest(g *Gig, path string, cert *x509.Certificate) string {
+	var state *tls.ConnectionState
+	if cert != nil {
+		state = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+	}
+
+	c, conn := g.NewFakeContext(path, state)
+	g.ServeGemini(c)
+
+	return conn.Written
+}
+
+func TestIdentityAuth(t *testing.T) {
+	is := is.New(t)
+
+	var (
+		store = NewIdentityMemoryStore()
+		alice = &x509.Certificate{Raw: []byte{1}, Subject: pkix.Name{CommonName: "alice"}}
+		bob   = &x509.Certificate{Raw: []byte{2}, Subject: pkix.Name{CommonName: "bob"}}
+		phone = &x509.Certificate{Raw: []byte{3}, Subject: pkix.Name{CommonName: "alice-phone"}}
+		seen  = 0
+	)
+
+	g := New()
+	config := IdentityAuthConfig{
+		Store: store,
+		OnFirstSeen: func(id *Identity, c Context) error {
+			seen++
+
+			if id.Name == "bob" {
+				return NewErrorFrom(ErrCertificateNotAuthorised, "No bobs")
+			}
+
+			id.Data = map[string]string{"via": c.Path()}
+
+			return nil
+		},
+	}
+	g.Use(IdentityAuthWithConfig(config))
+	g.Handle("/", func(c Context) error {
+		id := GetIdentity(c)
+		return c.Text("%s %d %s", id.Name, len(id.Fingerprints), id.Data["via"])
+	})
+	g.IdentityLinkHandle("/link", config)
+
+	is.Equal("60 Client Certificate Required\r\n", identityRequest(g, "/", nil))
+	is.Equal("20 text/plain\r\nalice 1 /", identityRequest(g, "/", alice))
+	is.Equal("20 text/plain\r\nalice 1 /", identityRequest(g, "/", alice))
+	is.Equal(1, seen)
+	is.Equal("61 No bobs\r\n", identityRequest(g, "/", bob))
+	is.Equal("61 No bobs\r\n", identityRequest(g, "/", bob))
+	is.Equal(3, seen)
+
+	id, err := store.Lookup(CertFingerprint(alice, FingerprintSHA256))
+	is.NoErr(err)
+	is.Equal("alice", id.Name)
+	is.Equal("4bf5122f344554c53bde2ebb8cd2b7e3d1600ad631c385a5d7cce23c7785459a", id.Fingerprints[0])
+
+	// Link another certificate
+	is.Equal("20 text/plain\r\nalice-phone 1 /", identityRequest(g, "/", phone))
+
+	b := identityRequest(g, "/link", alice)
+	is.Equal("20 text/gemini\r\n# Link certificate\n\nOpen the link below using another certificate and enter code ", b[:len(b)-len("XXXXXXXX\n\n=> /link/code")])
+	code := b[len(b)-len("XXXXXXXX\n\n=> /link/code") : len(b)-len("\n\n=> /link/code")]
+
+	is.Equal("10 Enter code\r\n", identityRequest(g, "/link/code", phone))
+	is.Equal("10 Invalid code, try again\r\n", identityRequest(g, "/link/code?nope", phone))
+	is.Equal("20 text/gemini\r\n# Certificate linked", identityRequest(g, "/link/code?"+code, phone))
+	is.Equal("10 Invalid code, try again\r\n", identityRequest(g, "/link/code?"+code, phone))
+	is.Equal("20 text/plain\r\nalice 2 /", identityRequest(g, "/", phone))
+
+	// Previous identity of phone is gone
+	is.Equal(1, len(store.identities))
+
+	is.NoErr(store.Unlink(CertFingerprint(phone, FingerprintSHA256)))
+	is.Equal("20 text/plain\r\nalice 1 /", identityRequest(g, "/", alice))
+	is.Equal(ErrIdentityNotFound, store.Link("missing", "fp"))
+}
+
+func TestIdentityAuth_Concurrent(t *testing.T) {
+	is := is.New(t)
+
+	var (
+		store = NewIdentityMemoryStore()
+		cert  = &x509.Certificate{Raw: []byte{1}, Subject: pkix.Name{CommonName: "alice"}}
+		wg    sync.WaitGroup
+		ids   = make([]string, 20)
+	)
+
+	g := New()
+	g.Use(IdentityAuth(store))
+	g.Handle("/", func(c Context) error {
+		return c.Text(GetIdentity(c).ID)
+	})
+
+	for i := range ids {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+			ids[i] = identityRequest(g, "/", cert)
+		}(i)
+	}
+
+	wg.Wait()
+
+	for _, id := range ids {
+		is.Equal(ids[0], id)
+	}
+
+	is.Equal(1, len(store.identities))
+}
+
+func TestIdentityAuth_Fingerprint(t *testing.T) {
+	is := is.New(t)
+
+	var (
+		store = NewIdentityMemoryStore()
+		cert  = &x509.Certificate{Raw: []byte{1}, Subject: pkix.Name{CommonName: "alice"}}
+	)
+
+	g := New()
+	g.Use(IdentityAuthWithConfig(IdentityAuthConfig{
+		Store:                store,
+		Fingerprint:          func(cert *x509.Certificate) string { return cert.Subject.CommonName },
+		FingerprintAlgorithm: FingerprintMD5,
+	}))
+	g.Handle("/", func(c Context) error {
+		return c.Text(GetIdentity(c).Name)
+	})
+
+	is.Equal("20 text/plain\r\nalice", identityRequest(g, "/", cert))
+
+	id, err := store.Lookup("alice")
+	is.NoErr(err)
+	is.True(id != nil)
+}
+
+func TestIdentityFileStore(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := ioutil.TempDir("", "gig")
+	is.NoErr(err)
+
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "identities.json")
+
+	s, err := NewIdentityFileStore(file)
+	is.NoErr(err)
+	is.NoErr(s.Save(&Identity{ID: "1", Name: "alice", Fingerprints: []string{"a"}}))
+	is.NoErr(s.Link("1", "b"))
+	is.NoErr(s.Save(&Identity{ID: "2", Name: "bob", Fingerprints: []string{"c"}}))
+	is.NoErr(s.Unlink("c"))
+
+	id, err := s.Register(&Identity{ID: "3", Name: "mallory", Fingerprints: []string{"b"}})
+	is.NoErr(err)
+	is.Equal("1", id.ID)
+
+	s, err = NewIdentityFileStore(file)
+	is.NoErr(err)
+
+	id, err = s.Lookup("b")
+	is.NoErr(err)
+	is.Equal("alice", id.Name)
+	is.Equal([]string{"a", "b"}, id.Fingerprints)
+
+	id, err = s.Get("2")
+	is.NoErr(err)
+	is.True(id == nil)
+
+	is.NoErr(ioutil.WriteFile(file, []byte("{"), 0600))
+
+	_, err = NewIdentityFileStore(file)
+	is.True(err != nil)
+}
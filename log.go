@@ -0,0 +1,127 @@
+package gig
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+)
+
+type (
+	// LeveledLogger receives diagnostics of the framework, such as accept
+	// errors, TLS handshake failures and invalid requests. Messages come with
+	// alternating key/value pairs.
+	//
+	// `*slog.Logger` of Go 1.21 satisfies LeveledLogger, so any slog handler
+	// can be used.
+	LeveledLogger interface {
+		Debug(msg string, kv ...interface{})
+		Info(msg string, kv ...interface{})
+		Warn(msg string, kv ...interface{})
+		Error(msg string, kv ...interface{})
+	}
+
+	// LogLevel is severity of a log message.
+	LogLevel int
+
+	// stdLogger adapts log.Logger to LeveledLogger.
+	stdLogger struct {
+		l   *log.Logger
+		min LogLevel
+	}
+
+	// debugLogger writes messages using debugPrintf, it is used unless
+	// `Gig#Logger` is set.
+	debugLogger struct{}
+)
+
+// Log levels.
+const (
+	LogDebug LogLevel = iota
+	LogInfo
+	LogWarn
+	LogError
+)
+
+// String returns name of level.
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "DEBUG"
+	case LogInfo:
+		return "INFO"
+	case LogWarn:
+		return "WARN"
+	case LogError:
+		return "ERROR"
+	}
+
+	return fmt.Sprintf("LogLevel(%d)", int(l))
+}
+
+// NewStdLogger returns a LeveledLogger that writes messages of min level and
+// above to l as "LEVEL msg key=value ...".
+func NewStdLogger(l *log.Logger, min LogLevel) LeveledLogger {
+	return &stdLogger{l: l, min: min}
+}
+
+func (s *stdLogger) Debug(msg string, kv ...interface{}) { s.log(LogDebug, msg, kv) }
+func (s *stdLogger) Info(msg string, kv ...interface{})  { s.log(LogInfo, msg, kv) }
+func (s *stdLogger) Warn(msg string, kv ...interface{})  { s.log(LogWarn, msg, kv) }
+func (s *stdLogger) Error(msg string, kv ...interface{}) { s.log(LogError, msg, kv) }
+
+func (s *stdLogger) log(level LogLevel, msg string, kv []interface{}) {
+	if level < s.min {
+		return
+	}
+
+	_ = s.l.Output(3, formatLog(level, msg, kv))
+}
+
+func (debugLogger) Debug(msg string, kv ...interface{}) { debugLog(LogDebug, msg, kv) }
+func (debugLogger) Info(msg string, kv ...interface{})  { debugLog(LogInfo, msg, kv) }
+func (debugLogger) Warn(msg string, kv ...interface{})  { debugLog(LogWarn, msg, kv) }
+func (debugLogger) Error(msg string, kv ...interface{}) { debugLog(LogError, msg, kv) }
+
+func debugLog(level LogLevel, msg string, kv []interface{}) {
+	debugPrintf("%s", formatLog(level, msg, kv))
+}
+
+// formatLog formats message as "LEVEL msg key=value ...", quoting values as
+// logfmt does.
+func formatLog(level LogLevel, msg string, kv []interface{}) string {
+	var buf bytes.Buffer
+
+	buf.WriteString(level.String())
+	buf.WriteByte(' ')
+	buf.WriteString(msg)
+
+	for i := 0; i < len(kv); i += 2 {
+		buf.WriteByte(' ')
+		buf.WriteString(fmt.Sprint(kv[i]))
+		buf.WriteByte('=')
+
+		var s string
+		if i+1 < len(kv) {
+			s = logString(kv[i+1])
+		}
+
+		if logfmtNeedsQuote(s) {
+			s = fmt.Sprintf("%q", s)
+		}
+
+		buf.WriteString(s)
+	}
+
+	return buf.String()
+}
+
+// logger returns Logger of g, of Gig it is mounted onto, or debugLogger.
+func (g *Gig) logger() LeveledLogger {
+	for ; g != nil; g = g.parent {
+		if g.Logger != nil {
+			return g.Logger
+		}
+	}
+
+	return debugLogger{}
+}
@@ -0,0 +1,53 @@
+package gig
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"sync"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+type recordLogger struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (r *recordLogger) Debug(msg string, kv ...interface{}) { r.log(LogDebug, msg, kv) }
+func (r *recordLogger) Info(msg string, kv ...interface{})  { r.log(LogInfo, msg, kv) }
+func (r *recordLogger) Warn(msg string, kv ...interface{})  { r.log(LogWarn, msg, kv) }
+func (r *recordLogger) Error(msg string, kv ...interface{}) { r.log(LogError, msg, kv) }
+
+func (r *recordLogger) log(level LogLevel, msg string, kv []interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.msgs = append(r.msgs, level.String()+" "+msg)
+}
+
+func (r *recordLogger) has(msg string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, m := range r.msgs {
+		if m == msg {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestStdLogger(t *testing.T) {
+	is := is.New(t)
+	buf := new(bytes.Buffer)
+	l := NewStdLogger(log.New(buf, "", 0), LogInfo)
+
+	l.Debug("hidden")
+	l.Warn("something failed", "remote_ip", "192.0.2.1", "error", errors.New("bad thing"), "odd")
+	l.Error("done", "bytes", 42)
+
+	is.Equal("WARN something failed remote_ip=192.0.2.1 error=\"bad thing\" odd=\"\"\nERROR done bytes=42\n", buf.String())
+}
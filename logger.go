@@ -2,11 +2,15 @@ package gig
 
 import (
 	"bytes"
+	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/valyala/fasttemplate"
 )
@@ -24,10 +28,12 @@ type (
 		// - time_rfc3339
 		// - time_rfc3339_nano
 		// - time_custom
+		// - id (Request ID set by RequestID middleware)
 		// - remote_ip
 		// - uri
 		// - host
 		// - path
+		// - route (Registered path of the handler)
 		// - status
 		// - error
 		// - latency (In nanoseconds)
@@ -36,18 +42,64 @@ type (
 		// - bytes_out (Bytes sent)
 		// - meta
 		// - query
+		// - cert_hash (Fingerprint of client certificate, see Fingerprint)
+		// - cert_subject
+		// - tls_version
+		// - ctx:<key> (Value set in context using `Context.Set()`)
 		//
 		// Example "${remote_ip} ${status}"
 		//
 		// Optional. Default value DefaultLoggerConfig.Format.
 		Format string
 
+		// Encoding defines how log lines are written. LoggerEncodingJSON and
+		// LoggerEncodingLogfmt write Fields, LoggerEncodingCommon and
+		// LoggerEncodingCombined write fixed fields, instead of using Format.
+		// Optional. Default value LoggerEncodingTemplate.
+		Encoding LoggerEncoding
+
+		// Fields are tags written by JSON and logfmt encodings, in order.
+		// Values are keyed by tag name, or by key for ctx:<key> tags. Tags
+		// without value, such as error of successful request, are left out.
+		// Optional. Default value DefaultLoggerConfig.Fields.
+		Fields []string
+
+		// Fingerprint defines how client certificate is fingerprinted for
+		// cert_hash tag and user field of Common and Combined encodings.
+		// Optional. Default value FingerprintSHA256.
+		Fingerprint FingerprintAlgorithm
+
 		// Optional. Default value DefaultLoggerConfig.CustomTimeFormat.
 		CustomTimeFormat string
 
+		// Output is where log lines are written.
+		// Optional. Default value DefaultWriter.
+		Output io.Writer
+
 		template *fasttemplate.Template
 		pool     *sync.Pool
 	}
+
+	// LoggerEncoding defines how Logger middleware writes log lines.
+	LoggerEncoding int
+)
+
+const (
+	// LoggerEncodingTemplate writes LoggerConfig.Format with tags replaced.
+	LoggerEncodingTemplate LoggerEncoding = iota
+	// LoggerEncodingJSON writes a JSON object per line.
+	LoggerEncodingJSON
+	// LoggerEncodingLogfmt writes key=value pairs per line.
+	LoggerEncodingLogfmt
+	// LoggerEncodingCommon writes Common Log Format lines adapted to Gemini,
+	// with fingerprint of client certificate as user and URL as request:
+	//
+	//	192.0.2.1 - 2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824 [18/Oct/2020:13:55:36 +0000] "gemini://example.com/" 20 1024
+	LoggerEncodingCommon
+	// LoggerEncodingCombined writes Combined Log Format lines adapted to
+	// Gemini, with response meta and subject of client certificate in place
+	// of referer and user agent.
+	LoggerEncodingCombined
 )
 
 var (
@@ -55,6 +107,7 @@ var (
 	DefaultLoggerConfig = LoggerConfig{
 		Skipper:          DefaultSkipper,
 		Format:           "time=\"${time_rfc3339}\" path=${path} status=${status} duration=${latency} ${error}\n",
+		Fields:           []string{"time_rfc3339", "remote_ip", "host", "path", "status", "latency", "bytes_out", "error"},
 		CustomTimeFormat: "2006-01-02 15:04:05.00000",
 	}
 )
@@ -76,6 +129,10 @@ func LoggerWithConfig(config LoggerConfig) MiddlewareFunc {
 		config.Format = DefaultLoggerConfig.Format
 	}
 
+	if len(config.Fields) == 0 {
+		config.Fields = DefaultLoggerConfig.Fields
+	}
+
 	config.template = fasttemplate.New(config.Format, "${", "}")
 	config.pool = &sync.Pool{
 		New: func() interface{} {
@@ -95,70 +152,266 @@ func LoggerWithConfig(config LoggerConfig) MiddlewareFunc {
 				c.Error(err)
 			}
 
-			stop := time.Now()
+			e := logEntry{c: c, err: err, start: start, stop: time.Now(), config: &config}
 			buf := config.pool.Get().(*bytes.Buffer)
 			buf.Reset()
 
 			defer config.pool.Put(buf)
 
-			res := c.Response()
-
-			if _, err = config.template.ExecuteFunc(buf, func(w io.Writer, tag string) (int, error) {
-				switch tag {
-				case "time_unix":
-					return buf.WriteString(strconv.FormatInt(time.Now().Unix(), 10))
-				case "time_unix_nano":
-					return buf.WriteString(strconv.FormatInt(time.Now().UnixNano(), 10))
-				case "time_rfc3339":
-					return buf.WriteString(time.Now().Format(time.RFC3339))
-				case "time_rfc3339_nano":
-					return buf.WriteString(time.Now().Format(time.RFC3339Nano))
-				case "time_custom":
-					return buf.WriteString(time.Now().Format(config.CustomTimeFormat))
-				case "remote_ip":
-					return buf.WriteString(c.IP())
-				case "host":
-					return buf.WriteString(c.URL().Host)
-				case "uri":
-					return buf.WriteString(c.RequestURI())
-				case "path":
-					p := c.URL().Path
-					if p == "" {
-						p = "/"
-					}
-					return buf.WriteString(p)
-				case "status":
-					return buf.WriteString(strconv.FormatInt(int64(res.Status), 10))
-				case "error":
-					if err != nil {
-						return buf.WriteString(err.Error())
-					}
-				case "latency":
-					ms := float64(stop.Sub(start)) / float64(time.Millisecond)
-					return fmt.Fprintf(buf, "%.2f", ms)
-				case "latency_human":
-					return buf.WriteString(stop.Sub(start).String())
-				case "bytes_in":
-					i := len(c.RequestURI())
-					return buf.WriteString(strconv.FormatInt(int64(i), 10))
-				case "bytes_out":
-					return buf.WriteString(strconv.FormatInt(res.Size, 10))
-				case "meta":
-					return buf.WriteString(res.Meta)
-				case "query":
-					query, err := c.QueryString()
-					if err == nil {
-						return buf.Write([]byte(query))
-					}
+			switch config.Encoding {
+			case LoggerEncodingJSON:
+				e.writeJSON(buf)
+			case LoggerEncodingLogfmt:
+				e.writeLogfmt(buf)
+			case LoggerEncodingCommon, LoggerEncodingCombined:
+				e.writeCommon(buf, config.Encoding == LoggerEncodingCombined)
+			default:
+				if _, err = config.template.ExecuteFunc(buf, func(w io.Writer, tag string) (int, error) {
+					return buf.WriteString(logString(e.value(tag)))
+				}); err != nil {
+					return
 				}
-				return 0, nil
-			}); err != nil {
-				return
 			}
 
-			fmt.Fprint(DefaultWriter, buf.String())
+			out := config.Output
+			if out == nil {
+				out = DefaultWriter
+			}
+
+			_, err = out.Write(buf.Bytes())
 
 			return
 		}
 	}
 }
+
+// logEntry computes tag values of a logged request.
+type logEntry struct {
+	c           Context
+	err         error
+	start, stop time.Time
+	config      *LoggerConfig
+}
+
+// value returns value of tag as json.Number for numbers, string, value from
+// context for ctx:<key> tags, or nil if there is none.
+func (e *logEntry) value(tag string) interface{} {
+	var (
+		c   = e.c
+		res = c.Response()
+	)
+
+	switch tag {
+	case "time_unix":
+		return json.Number(strconv.FormatInt(time.Now().Unix(), 10))
+	case "time_unix_nano":
+		return json.Number(strconv.FormatInt(time.Now().UnixNano(), 10))
+	case "time_rfc3339":
+		return time.Now().Format(time.RFC3339)
+	case "time_rfc3339_nano":
+		return time.Now().Format(time.RFC3339Nano)
+	case "time_custom":
+		return time.Now().Format(e.config.CustomTimeFormat)
+	case "id":
+		if id := GetRequestID(c); id != "" {
+			return id
+		}
+	case "remote_ip":
+		return c.IP()
+	case "host":
+		return c.URL().Host
+	case "uri":
+		return c.RequestURI()
+	case "path":
+		p := c.URL().Path
+		if p == "" {
+			p = "/"
+		}
+		return p
+	case "route":
+		return c.Path()
+	case "status":
+		return json.Number(strconv.FormatInt(int64(res.Status), 10))
+	case "error":
+		if e.err != nil {
+			return e.err.Error()
+		}
+	case "latency":
+		ms := float64(e.stop.Sub(e.start)) / float64(time.Millisecond)
+		return json.Number(fmt.Sprintf("%.2f", ms))
+	case "latency_human":
+		return e.stop.Sub(e.start).String()
+	case "bytes_in":
+		return json.Number(strconv.Itoa(len(c.RequestURI())))
+	case "bytes_out":
+		return json.Number(strconv.FormatInt(res.Size, 10))
+	case "meta":
+		return res.Meta
+	case "query":
+		if query, err := c.QueryString(); err == nil {
+			return query
+		}
+	case "cert_hash":
+		if h := c.CertFingerprint(e.config.Fingerprint); h != "" {
+			return h
+		}
+	case "cert_subject":
+		if cert := c.Certificate(); cert != nil {
+			return cert.Subject.String()
+		}
+	case "tls_version":
+		if state := c.ConnectionState(); state != nil {
+			return tlsVersionName(state.Version)
+		}
+	default:
+		if strings.HasPrefix(tag, "ctx:") {
+			return c.Get(tag[len("ctx:"):])
+		}
+	}
+
+	return nil
+}
+
+func (e *logEntry) writeJSON(buf *bytes.Buffer) {
+	buf.WriteByte('{')
+
+	first := true
+
+	for _, tag := range e.config.Fields {
+		v := e.value(tag)
+		if v == nil {
+			continue
+		}
+
+		b, err := json.Marshal(v)
+		if err != nil {
+			b, _ = json.Marshal(fmt.Sprint(v))
+		}
+
+		if !first {
+			buf.WriteByte(',')
+		}
+
+		first = false
+		k, _ := json.Marshal(logKey(tag))
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(b)
+	}
+
+	buf.WriteString("}\n")
+}
+
+func (e *logEntry) writeLogfmt(buf *bytes.Buffer) {
+	first := true
+
+	for _, tag := range e.config.Fields {
+		v := e.value(tag)
+		if v == nil {
+			continue
+		}
+
+		if !first {
+			buf.WriteByte(' ')
+		}
+
+		first = false
+		buf.WriteString(logKey(tag))
+		buf.WriteByte('=')
+
+		s := logString(v)
+		if _, ok := v.(json.Number); ok || !logfmtNeedsQuote(s) {
+			buf.WriteString(s)
+		} else {
+			buf.WriteString(strconv.Quote(s))
+		}
+	}
+
+	buf.WriteByte('\n')
+}
+
+func (e *logEntry) writeCommon(buf *bytes.Buffer, combined bool) {
+	var (
+		c    = e.c
+		res  = c.Response()
+		user = c.CertFingerprint(e.config.Fingerprint)
+		size = "-"
+	)
+
+	if user == "" {
+		user = "-"
+	}
+
+	if res.Size > 0 {
+		size = strconv.FormatInt(res.Size, 10)
+	}
+
+	fmt.Fprintf(buf, "%s - %s [%s] %s %d %s", c.IP(), user, e.start.Format("02/Jan/2006:15:04:05 -0700"),
+		commonQuote(c.RequestURI()), res.Status, size)
+
+	if combined {
+		subject := ""
+		if cert := c.Certificate(); cert != nil {
+			subject = cert.Subject.String()
+		}
+
+		fmt.Fprintf(buf, " %s %s", commonQuote(res.Meta), commonQuote(subject))
+	}
+
+	buf.WriteByte('\n')
+}
+
+// commonQuote quotes s for Common Log Format, "-" is used for empty s.
+func commonQuote(s string) string {
+	if s == "" {
+		return `"-"`
+	}
+
+	return strconv.Quote(s)
+}
+
+func logKey(tag string) string {
+	return strings.TrimPrefix(tag, "ctx:")
+}
+
+func logString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func logfmtNeedsQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == 0x7f || r >= utf8.RuneSelf {
+			return true
+		}
+	}
+
+	return false
+}
+
+func tlsVersionName(v uint16) string {
+	switch v {
+	case tls.VersionTLS10:
+		return "TLS1.0"
+	case tls.VersionTLS11:
+		return "TLS1.1"
+	case tls.VersionTLS12:
+		return "TLS1.2"
+	case tls.VersionTLS13:
+		return "TLS1.3"
+	}
+
+	return fmt.Sprintf("0x%04x", v)
+}
@@ -2,6 +2,9 @@ package gig
 
 import (
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
 	"errors"
 	"strings"
@@ -136,3 +139,92 @@ func TestLoggerCustomTimestamp(t *testing.T) {
 	_, err := time.Parse(customTimeFormat, loggedTime)
 	is.True(err != nil)
 }
+
+func TestLoggerJSON(t *testing.T) {
+	is := is.New(t)
+	buf := new(bytes.Buffer)
+
+	g := New()
+	g.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			c.Set("user", "jon")
+			return next(c)
+		}
+	})
+	g.Use(LoggerWithConfig(LoggerConfig{
+		Encoding: LoggerEncodingJSON,
+		Fields:   []string{"path", "route", "status", "error", "cert_hash", "cert_subject", "tls_version", "ctx:user", "ctx:missing"},
+		Output:   buf,
+	}))
+
+	g.Handle("/user/:name", func(c Context) error {
+		return errors.New("oops \"quoted\"\n")
+	})
+
+	cert := &x509.Certificate{Raw: []byte{1}, Subject: pkix.Name{CommonName: "jon"}}
+	c, _ := g.NewFakeContext("/user/jon", &tls.ConnectionState{
+		Version:          tls.VersionTLS13,
+		PeerCertificates: []*x509.Certificate{cert},
+	})
+	g.ServeGemini(c)
+
+	is.Equal(`{"path":"/user/jon","route":"/user/:name","status":50,"error":"oops \"quoted\"\n",`+
+		`"cert_hash":"`+CertFingerprint(cert, FingerprintSHA256)+`","cert_subject":"CN=jon","tls_version":"TLS1.3","user":"jon"}`+"\n", buf.String())
+
+	var obj map[string]interface{}
+	is.NoErr(json.Unmarshal(buf.Bytes(), &obj))
+}
+
+func TestLoggerLogfmt(t *testing.T) {
+	is := is.New(t)
+	buf := new(bytes.Buffer)
+
+	g := New()
+	g.Use(LoggerWithConfig(LoggerConfig{
+		Encoding: LoggerEncodingLogfmt,
+		Fields:   []string{"remote_ip", "path", "query", "status", "meta", "error"},
+		Output:   buf,
+	}))
+
+	g.Handle("/*", func(c Context) error {
+		return c.NoContent(StatusInput, "Enter name")
+	})
+
+	c, _ := g.NewFakeContext("/search?a=b", nil)
+	g.ServeGemini(c)
+
+	is.Equal(`remote_ip=192.0.2.1 path=/search query="a=b" status=10 meta="Enter name"`+"\n", buf.String())
+}
+
+func TestLoggerCommon(t *testing.T) {
+	is := is.New(t)
+	buf := new(bytes.Buffer)
+
+	g := New()
+	g.Use(LoggerWithConfig(LoggerConfig{Encoding: LoggerEncodingCombined, Output: buf}))
+
+	g.Handle("/*", func(c Context) error {
+		return c.NoContent(StatusInput, "Enter \"name\"")
+	})
+
+	cert := &x509.Certificate{Raw: []byte{1}, Subject: pkix.Name{CommonName: "jon"}}
+	c, _ := g.NewFakeContext("/search", &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}})
+	g.ServeGemini(c)
+
+	line := buf.String()
+	is.True(strings.HasPrefix(line, "192.0.2.1 - "+CertFingerprint(cert, FingerprintSHA256)+" ["))
+	is.True(strings.HasSuffix(line, `] "/search" 10 17 "Enter \"name\"" "CN=jon"`+"\n"))
+
+	buf.Reset()
+	g = New()
+	g.Use(LoggerWithConfig(LoggerConfig{Encoding: LoggerEncodingCommon, Output: buf}))
+	g.Handle("/", func(c Context) error {
+		return c.Gemini("hello")
+	})
+
+	c, _ = g.NewFakeContext("/", nil)
+	g.ServeGemini(c)
+
+	is.True(strings.HasPrefix(buf.String(), "192.0.2.1 - - ["))
+	is.True(strings.HasSuffix(buf.String(), `] "/" 20 21`+"\n"))
+}
@@ -0,0 +1,304 @@
+package gig
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// MetricsRegistry collects connection and request metrics and writes them
+	// in Prometheus text exposition format. Set it as `Gig#Metrics` to count
+	// connections and use Metrics middleware to observe requests. It is safe
+	// for concurrent use.
+	MetricsRegistry struct {
+		// Counters are accessed atomically and kept first for alignment
+		accepted          int64
+		active            int64
+		handshakeFailures int64
+
+		// Buckets are upper bounds in seconds of request duration histogram.
+		// Must be sorted and not changed after first request.
+		// Default value DefaultMetricsBuckets.
+		Buckets []float64
+
+		mu            sync.Mutex
+		requestErrors map[string]int64                 // By reason
+		requests      map[metricsKey]*metricsHistogram // By route and status class
+	}
+
+	// MetricsConfig defines the config for Metrics middleware.
+	MetricsConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Registry receives metrics.
+		// Optional. Default value `Gig#Metrics` of Gig serving the request.
+		Registry *MetricsRegistry
+	}
+
+	metricsKey struct {
+		route  string
+		status string
+	}
+
+	metricsHistogram struct {
+		counts []int64 // Per bucket, not cumulative
+		count  int64
+		sum    float64
+	}
+)
+
+var (
+	// DefaultMetricsConfig is the default Metrics middleware config.
+	DefaultMetricsConfig = MetricsConfig{
+		Skipper: DefaultSkipper,
+	}
+
+	// DefaultMetricsBuckets are default request duration buckets in seconds.
+	DefaultMetricsBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+)
+
+// NewMetricsRegistry returns an empty MetricsRegistry.
+func NewMetricsRegistry() *MetricsRegistry {
+	return &MetricsRegistry{
+		Buckets:       DefaultMetricsBuckets,
+		requestErrors: map[string]int64{},
+		requests:      map[metricsKey]*metricsHistogram{},
+	}
+}
+
+// Metrics returns a middleware that observes duration of requests by route
+// and status class into `Gig#Metrics`. Requests are not observed if Gig has
+// no registry.
+func Metrics() MiddlewareFunc {
+	return MetricsWithConfig(DefaultMetricsConfig)
+}
+
+// MetricsWithConfig returns a Metrics middleware with config.
+// See: `Metrics()`.
+func MetricsWithConfig(config MetricsConfig) MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultMetricsConfig.Skipper
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c Context) (err error) {
+			r := config.Registry
+			if r == nil {
+				r = c.Gig().metrics()
+			}
+
+			if r == nil || config.Skipper(c) {
+				return next(c)
+			}
+
+			start := time.Now()
+
+			if err = next(c); err != nil {
+				c.Error(err)
+			}
+
+			// Unmatched paths are not used as labels, to keep their number
+			// bounded
+			route := c.Path()
+			if ctx, ok := c.(*context); ok && !ctx.matched {
+				route = ""
+			}
+
+			r.observe(route, c.Response().Status, time.Since(start))
+
+			return nil
+		}
+	}
+}
+
+// Handler returns a Gemini handler that responds with metrics.
+func (r *MetricsRegistry) Handler() HandlerFunc {
+	return func(c Context) error {
+		var buf bytes.Buffer
+
+		_, _ = r.WriteTo(&buf)
+
+		return c.Blob("text/plain; version=0.0.4", buf.Bytes())
+	}
+}
+
+// ServeHTTP implements http.Handler, so that metrics can be scraped over HTTP.
+func (r *MetricsRegistry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_, _ = r.WriteTo(w)
+}
+
+// WriteTo writes metrics to w in Prometheus text exposition format.
+func (r *MetricsRegistry) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+
+	writeMetric(&buf, "gig_connections_accepted_total", "counter", "Connections accepted.")
+	fmt.Fprintf(&buf, "gig_connections_accepted_total %d\n", atomic.LoadInt64(&r.accepted))
+
+	writeMetric(&buf, "gig_connections_active", "gauge", "Connections being served.")
+	fmt.Fprintf(&buf, "gig_connections_active %d\n", atomic.LoadInt64(&r.active))
+
+	writeMetric(&buf, "gig_tls_handshake_failures_total", "counter", "TLS handshakes that failed.")
+	fmt.Fprintf(&buf, "gig_tls_handshake_failures_total %d\n", atomic.LoadInt64(&r.handshakeFailures))
+
+	r.mu.Lock()
+
+	writeMetric(&buf, "gig_request_errors_total", "counter", "Requests that could not be read or parsed, by reason.")
+
+	reasons := make([]string, 0, len(r.requestErrors))
+	for reason := range r.requestErrors {
+		reasons = append(reasons, reason)
+	}
+
+	sort.Strings(reasons)
+
+	for _, reason := range reasons {
+		fmt.Fprintf(&buf, "gig_request_errors_total{reason=\"%s\"} %d\n", escapeLabel(reason), r.requestErrors[reason])
+	}
+
+	writeMetric(&buf, "gig_request_duration_seconds", "histogram", "Duration of requests, by route and status class.")
+
+	keys := make([]metricsKey, 0, len(r.requests))
+	for k := range r.requests {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+
+		return keys[i].status < keys[j].status
+	})
+
+	for _, k := range keys {
+		var (
+			h      = r.requests[k]
+			labels = fmt.Sprintf("route=\"%s\",status=\"%s\"", escapeLabel(k.route), k.status)
+			cum    int64
+		)
+
+		for i, le := range r.buckets() {
+			cum += h.counts[i]
+			fmt.Fprintf(&buf, "gig_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels, formatFloat(le), cum)
+		}
+
+		fmt.Fprintf(&buf, "gig_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(&buf, "gig_request_duration_seconds_sum{%s} %s\n", labels, formatFloat(h.sum))
+		fmt.Fprintf(&buf, "gig_request_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+
+	r.mu.Unlock()
+
+	return buf.WriteTo(w)
+}
+
+func (r *MetricsRegistry) buckets() []float64 {
+	if r.Buckets == nil {
+		return DefaultMetricsBuckets
+	}
+
+	return r.Buckets
+}
+
+// observe records request duration d of route with status.
+func (r *MetricsRegistry) observe(route string, status Status, d time.Duration) {
+	var (
+		k       = metricsKey{route: route, status: strconv.Itoa(int(status)/10) + "x"}
+		seconds = d.Seconds()
+		buckets = r.buckets()
+	)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.requests == nil {
+		r.requests = map[metricsKey]*metricsHistogram{}
+	}
+
+	h := r.requests[k]
+	if h == nil {
+		h = &metricsHistogram{counts: make([]int64, len(buckets))}
+		r.requests[k] = h
+	}
+
+	if i := sort.SearchFloat64s(buckets, seconds); i < len(buckets) {
+		h.counts[i]++
+	}
+
+	h.count++
+	h.sum += seconds
+}
+
+func (r *MetricsRegistry) connOpened() {
+	if r == nil {
+		return
+	}
+
+	atomic.AddInt64(&r.accepted, 1)
+	atomic.AddInt64(&r.active, 1)
+}
+
+func (r *MetricsRegistry) connClosed() {
+	if r == nil {
+		return
+	}
+
+	atomic.AddInt64(&r.active, -1)
+}
+
+func (r *MetricsRegistry) handshakeFailed() {
+	if r == nil {
+		return
+	}
+
+	atomic.AddInt64(&r.handshakeFailures, 1)
+}
+
+func (r *MetricsRegistry) requestError(reason string) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.requestErrors == nil {
+		r.requestErrors = map[string]int64{}
+	}
+
+	r.requestErrors[reason]++
+}
+
+// metrics returns Metrics of g or of Gig it is mounted onto, or nil.
+func (g *Gig) metrics() *MetricsRegistry {
+	for ; g != nil; g = g.parent {
+		if g.Metrics != nil {
+			return g.Metrics
+		}
+	}
+
+	return nil
+}
+
+func writeMetric(buf *bytes.Buffer, name, typ, help string) {
+	fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,57 @@
+package gig
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestMetrics(t *testing.T) {
+	is := is.New(t)
+
+	g := New()
+	g.Metrics = NewMetricsRegistry()
+	g.Metrics.Buckets = []float64{0.05, 1}
+	g.Use(Metrics())
+
+	g.Handle("/user/:name", func(c Context) error {
+		return c.Gemini("hello")
+	})
+	g.Handle("/slow", func(c Context) error {
+		time.Sleep(60 * time.Millisecond)
+		return NewError(StatusTemporaryFailure, "try later")
+	})
+	g.Handle("/metrics", g.Metrics.Handler())
+
+	for _, path := range []string{"/user/a", "/user/b", "/slow", "/missing"} {
+		c, _ := g.NewFakeContext(path, nil)
+		g.ServeGemini(c)
+	}
+
+	g.Metrics.requestError("url")
+
+	body := request("/metrics", g)
+
+	for _, line := range []string{
+		"20 text/plain; version=0.0.4\r\n",
+		"# TYPE gig_request_duration_seconds histogram\n",
+		"gig_request_duration_seconds_bucket{route=\"/user/:name\",status=\"2x\",le=\"0.05\"} 2\n",
+		"gig_request_duration_seconds_count{route=\"/user/:name\",status=\"2x\"} 2\n",
+		"gig_request_duration_seconds_bucket{route=\"/slow\",status=\"4x\",le=\"0.05\"} 0\n",
+		"gig_request_duration_seconds_bucket{route=\"/slow\",status=\"4x\",le=\"1\"} 1\n",
+		"gig_request_duration_seconds_bucket{route=\"/slow\",status=\"4x\",le=\"+Inf\"} 1\n",
+		"gig_request_duration_seconds_count{route=\"\",status=\"5x\"} 1\n",
+		"gig_request_errors_total{reason=\"url\"} 1\n",
+	} {
+		is.True(strings.Contains(body, line)) // metrics contain line
+	}
+
+	// Over HTTP
+	rec := httptest.NewRecorder()
+	g.Metrics.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	is.Equal("text/plain; version=0.0.4", rec.Header().Get("Content-Type"))
+	is.True(strings.Contains(rec.Body.String(), "gig_connections_active 0\n"))
+}
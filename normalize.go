@@ -0,0 +1,228 @@
+package gig
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+type (
+	// NormalizeConfig defines the config for Normalize middleware.
+	NormalizeConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// TrailingSlash defines whether trailing slash is added, removed or
+		// kept as is.
+		// Optional. Default value TrailingSlashKeep.
+		TrailingSlash TrailingSlash
+
+		// CollapseSlashes replaces repeated slashes with a single one.
+		CollapseSlashes bool
+
+		// ResolveDots removes "." and ".." path segments.
+		ResolveDots bool
+
+		// LowercaseHost converts host to lower case.
+		LowercaseHost bool
+
+		// StripDefaultPort removes default Gemini port 1965 from host.
+		StripDefaultPort bool
+
+		// RedirectCode is the status code used to redirect to canonical URL.
+		// Optional. Default value StatusRedirectPermanent.
+		RedirectCode Status
+
+		// Rewrite modifies request URL in place instead of redirecting, so the
+		// router matches canonical path.
+		// Optional. Default value false.
+		Rewrite bool
+	}
+
+	// TrailingSlash defines trailing slash policy of Normalize middleware.
+	TrailingSlash uint8
+)
+
+// Trailing slash policies.
+const (
+	TrailingSlashKeep TrailingSlash = iota
+	TrailingSlashAdd
+	TrailingSlashRemove
+)
+
+const defaultPort = "1965"
+
+var (
+	// DefaultNormalizeConfig is the default Normalize middleware config.
+	DefaultNormalizeConfig = NormalizeConfig{
+		Skipper:          DefaultSkipper,
+		TrailingSlash:    TrailingSlashKeep,
+		CollapseSlashes:  true,
+		ResolveDots:      true,
+		LowercaseHost:    true,
+		StripDefaultPort: true,
+		RedirectCode:     StatusRedirectPermanent,
+	}
+)
+
+// Normalize returns a middleware that redirects requests to canonical URL,
+// collapsing duplicate slashes, resolving dot segments, lower casing host and
+// removing default port. It must be registered using `Gig#Pre()`.
+func Normalize() MiddlewareFunc {
+	return NormalizeWithConfig(DefaultNormalizeConfig)
+}
+
+// AddTrailingSlash returns a middleware that redirects requests without
+// trailing slash to the same path with trailing slash.
+// It must be registered using `Gig#Pre()`.
+func AddTrailingSlash() MiddlewareFunc {
+	return NormalizeWithConfig(NormalizeConfig{TrailingSlash: TrailingSlashAdd})
+}
+
+// RemoveTrailingSlash returns a middleware that redirects requests with
+// trailing slash to the same path without it.
+// It must be registered using `Gig#Pre()`.
+func RemoveTrailingSlash() MiddlewareFunc {
+	return NormalizeWithConfig(NormalizeConfig{TrailingSlash: TrailingSlashRemove})
+}
+
+// NormalizeWithConfig returns a Normalize middleware with config.
+// See: `Normalize()`.
+func NormalizeWithConfig(config NormalizeConfig) MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultNormalizeConfig.Skipper
+	}
+
+	if config.RedirectCode == 0 {
+		config.RedirectCode = DefaultNormalizeConfig.RedirectCode
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			var (
+				u       = c.URL()
+				escaped = u.EscapedPath()
+				rawPath = config.normalizePath(escaped)
+				host    = config.normalizeHost(u.Host)
+			)
+
+			if rawPath == escaped && host == u.Host {
+				return next(c)
+			}
+
+			// Path is normalized in escaped form, so that encoded slashes
+			// stay part of their segment
+			path, err := url.PathUnescape(rawPath)
+			if err != nil {
+				return next(c)
+			}
+
+			if (&url.URL{Path: path}).EscapedPath() == rawPath {
+				rawPath = ""
+			}
+
+			if config.Rewrite {
+				u.Path, u.RawPath, u.Host = path, rawPath, host
+				return next(c)
+			}
+
+			canonical := *u
+			canonical.Path, canonical.RawPath, canonical.Host = path, rawPath, host
+
+			// Redirect is resolved against URL before mount prefix was
+			// stripped, so the prefix is put back
+			if prefix := c.Gig().mountPath(); prefix != "" {
+				canonical.Path = prefix + canonical.Path
+
+				if canonical.RawPath != "" {
+					canonical.RawPath = prefix + canonical.RawPath
+				}
+			}
+
+			return c.Redirect(config.RedirectCode, canonical.String())
+		}
+	}
+}
+
+func (config *NormalizeConfig) normalizePath(p string) string {
+	if config.CollapseSlashes {
+		for strings.Contains(p, "//") {
+			p = strings.Replace(p, "//", "/", -1)
+		}
+	}
+
+	if config.ResolveDots {
+		p = resolveDotSegments(p)
+	}
+
+	switch config.TrailingSlash {
+	case TrailingSlashAdd:
+		if !strings.HasSuffix(p, "/") {
+			p += "/"
+		}
+	case TrailingSlashRemove:
+		if len(p) > 1 && strings.HasSuffix(p, "/") {
+			p = strings.TrimRight(p, "/")
+			if p == "" {
+				p = "/"
+			}
+		}
+	}
+
+	return p
+}
+
+func (config *NormalizeConfig) normalizeHost(h string) string {
+	if config.LowercaseHost {
+		h = strings.ToLower(h)
+	}
+
+	if config.StripDefaultPort {
+		if host, port, err := net.SplitHostPort(h); err == nil && port == defaultPort {
+			h = host
+			if strings.Contains(h, ":") {
+				h = "[" + h + "]" // IPv6
+			}
+		}
+	}
+
+	return h
+}
+
+// resolveDotSegments removes "." and ".." segments from path as described in
+// RFC 3986, section 5.2.4.
+func resolveDotSegments(p string) string {
+	if !strings.Contains(p, ".") {
+		return p
+	}
+
+	var (
+		segments = strings.Split(p, "/")
+		out      = make([]string, 0, len(segments))
+		last     = len(segments) - 1
+	)
+
+	for i, s := range segments {
+		switch s {
+		case ".":
+		case "..":
+			if len(out) > 1 {
+				out = out[:len(out)-1]
+			}
+		default:
+			out = append(out, s)
+			continue
+		}
+
+		if i == last {
+			out = append(out, "")
+		}
+	}
+
+	return strings.Join(out, "/")
+}
@@ -0,0 +1,78 @@
+package gig
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestNormalize(t *testing.T) {
+	is := is.New(t)
+
+	g := New()
+	g.Pre(Normalize())
+	g.Handle("/docs/*", func(c Context) error {
+		return c.Text("%s", c.URL().String())
+	})
+
+	is.Equal("31 /docs/a\r\n", request("/docs//a", g))
+	is.Equal("31 /docs/b\r\n", request("/docs/a/../b", g))
+	is.Equal("31 /docs/\r\n", request("/docs/a/..", g))
+	is.Equal("31 /docs/a/\r\n", request("/docs/./a/.", g))
+	is.Equal("31 /\r\n", request("/../..", g))
+	is.Equal("31 gemini://example.org/docs/a?q\r\n", request("gemini://Example.ORG:1965/docs/a?q", g))
+	is.Equal("31 gemini://[::1]/docs/a\r\n", request("gemini://[::1]:1965/docs/a", g))
+	is.Equal("20 text/plain\r\ngemini://example.org:1966/docs/a", request("gemini://example.org:1966/docs/a", g))
+	is.Equal("20 text/plain\r\n/docs/a.gmi", request("/docs/a.gmi", g))
+
+	// Encoded slashes are part of segment
+	is.Equal("20 text/plain\r\n/docs/a%2F%2Fb", request("/docs/a%2F%2Fb", g))
+	is.Equal("20 text/plain\r\n/docs/a%2F..%2Fb", request("/docs/a%2F..%2Fb", g))
+	is.Equal("31 /docs/a%2Fb\r\n", request("/docs//a%2Fb", g))
+	is.Equal("31 /docs/a%2Fb\r\n", request("/docs/x/../a%2Fb", g))
+}
+
+func TestNormalize_TrailingSlash(t *testing.T) {
+	is := is.New(t)
+
+	g := New()
+	g.Pre(AddTrailingSlash())
+	g.Static("/images", "_fixture/images")
+
+	is.Equal("31 /images/\r\n", request("/images", g))
+	is.Equal("31 gemini://example.org/\r\n", request("gemini://example.org", g))
+
+	g = New()
+	g.Pre(RemoveTrailingSlash())
+	g.Handle("/docs", func(c Context) error {
+		return c.Text("docs")
+	})
+
+	is.Equal("31 /docs\r\n", request("/docs/", g))
+	is.Equal("31 /docs\r\n", request("/docs//", g))
+	is.Equal("51 Not Found\r\n", request("/", g))
+}
+
+func TestNormalize_Rewrite(t *testing.T) {
+	is := is.New(t)
+
+	g := New()
+	g.Pre(NormalizeWithConfig(NormalizeConfig{
+		TrailingSlash:   TrailingSlashRemove,
+		CollapseSlashes: true,
+		Rewrite:         true,
+	}))
+	g.Handle("/docs/:id", func(c Context) error {
+		return c.Text("%s", c.Param("id"))
+	})
+
+	is.Equal("20 text/plain\r\na", request("/docs//a/", g))
+	is.Equal("20 text/plain\r\na%2Fb", request("/docs/a%2Fb/", g))
+
+	g.Handle("/raw/*", func(c Context) error {
+		return c.Text("%s %s", c.URL().Path, c.URL().EscapedPath())
+	})
+
+	is.Equal("20 text/plain\r\n/raw/a//b /raw/a%2F%2Fb", request("/raw//a%2F%2Fb/", g))
+	is.Equal("20 text/plain\r\n/raw/a b /raw/a%20b", request("/raw/a%20b/", g))
+}
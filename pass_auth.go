@@ -8,25 +8,109 @@ type (
 	// It may pin certificate to user if login is successful.
 	// Must return path to redirect to after login.
 	PassAuthLogin func(username, password, sig string, c Context) (string, error)
+	// PassAuthMigrate defines a function to replace a stored legacy
+	// certificate fingerprint with a new one.
+	PassAuthMigrate func(legacy, sig string, c Context) error
+
+	// PassAuthConfig defines the config for PassAuth middleware.
+	PassAuthConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Check validates certificate fingerprint.
+		// Required.
+		Check PassAuthCertCheck
+
+		// Fingerprint defines how certificate fingerprint passed to Check is
+		// computed. It must match fingerprint used by login handler.
+		// Optional. Default value FingerprintSHA256.
+		Fingerprint FingerprintAlgorithm
+
+		// Migrate is called when certificate is only known by its legacy
+		// MD5 fingerprint, as used by `PassAuth()`, so that stored
+		// fingerprint can be replaced. Request is then let through.
+		// Optional.
+		Migrate PassAuthMigrate
+	}
+
+	// PassAuthLoginConfig defines the config for login handlers.
+	PassAuthLoginConfig struct {
+		// Login checks username and password.
+		// Required.
+		Login PassAuthLogin
+
+		// Fingerprint defines how certificate fingerprint passed to Login is
+		// computed.
+		// Optional. Default value FingerprintSHA256.
+		Fingerprint FingerprintAlgorithm
+
+		// TOTP enables second factor. Users enrolled in TOTP are asked for a
+		// code or recovery code after their password is authenticated by
+		// store, and only then is Login called.
+		// Optional.
+		TOTP PassAuthTOTPStore
+
+		// Limiter locks out username, certificate and IP address after
+		// failed logins, responding with 44 SLOW DOWN. Every error returned
+		// by Login counts as failure.
+		// Optional.
+		Limiter *PassAuthLimiter
+	}
+)
+
+var (
+	// DefaultPassAuthConfig is the default PassAuth middleware config.
+	DefaultPassAuthConfig = PassAuthConfig{
+		Skipper:     DefaultSkipper,
+		Fingerprint: FingerprintSHA256,
+	}
 )
 
 // PassAuth is a middleware that implements username/password authentication
 // by first requiring a certificate, checking username/password using PassAuthValidator,
-// and then pinning certificate to it.
+// and then pinning certificate to it. Certificates are identified by MD5
+// fingerprint, use PassAuthWithConfig for SHA-256.
 //
 // For valid credentials it calls the next handler.
 func PassAuth(check PassAuthCertCheck) MiddlewareFunc {
+	c := DefaultPassAuthConfig
+	c.Check = check
+	c.Fingerprint = FingerprintMD5
+
+	return PassAuthWithConfig(c)
+}
+
+// PassAuthWithConfig returns a PassAuth middleware with config.
+// See `PassAuth()`.
+func PassAuthWithConfig(config PassAuthConfig) MiddlewareFunc {
+	// Defaults
+	if config.Check == nil {
+		panic("gig: pass auth middleware requires a check function")
+	}
+
+	if config.Skipper == nil {
+		config.Skipper = DefaultPassAuthConfig.Skipper
+	}
+
 	return func(next HandlerFunc) HandlerFunc {
 		return func(c Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
 			// If no client certificate is sent, request it
-			var sig = c.CertHash()
+			var sig = c.CertFingerprint(config.Fingerprint)
 			if sig == "" {
 				return c.NoContent(StatusClientCertificateRequired, "Please create a certificate")
 			}
 
-			to, err := check(sig, c)
+			to, err := config.Check(sig, c)
+			if err == nil && to != "" && config.Migrate != nil && config.Fingerprint != FingerprintMD5 {
+				to, err = config.migrate(sig, c)
+			}
+
 			if err != nil {
-				debugPrintf("gemini: could not check certificate: %s", err)
+				c.Gig().logger().Error("could not check certificate", "error", err)
 				return c.NoContent(StatusBadRequest, "Try again later")
 			}
 
@@ -39,8 +123,38 @@ func PassAuth(check PassAuthCertCheck) MiddlewareFunc {
 	}
 }
 
+// migrate checks legacy fingerprint of certificate and, if it is known,
+// replaces it with sig.
+func (config *PassAuthConfig) migrate(sig string, c Context) (string, error) {
+	legacy := c.CertFingerprint(FingerprintMD5)
+
+	to, err := config.Check(legacy, c)
+	if err != nil || to != "" {
+		return to, err
+	}
+
+	return "", config.Migrate(legacy, sig, c)
+}
+
 // PassAuthLoginHandle sets up handlers to check username/password using PassAuthLogin.
+// Certificates are identified by MD5 fingerprint, use
+// PassAuthLoginHandleWithConfig for SHA-256.
 func (g *Gig) PassAuthLoginHandle(path string, fn PassAuthLogin) {
+	g.PassAuthLoginHandleWithConfig(path, PassAuthLoginConfig{
+		Login:       fn,
+		Fingerprint: FingerprintMD5,
+	})
+}
+
+// PassAuthLoginHandleWithConfig sets up login handlers with config.
+// See `PassAuthLoginHandle()`.
+func (g *Gig) PassAuthLoginHandleWithConfig(path string, config PassAuthLoginConfig) {
+	if config.Login == nil {
+		panic("gig: pass auth login handler requires a login function")
+	}
+
+	prompts := newPassAuthPrompts()
+
 	g.Handle(path, func(c Context) error {
 		cert := c.Certificate()
 		if cert == nil {
@@ -48,7 +162,7 @@ func (g *Gig) PassAuthLoginHandle(path string, fn PassAuthLogin) {
 		}
 		username, err := c.QueryString()
 		if err != nil {
-			debugPrintf("gemini: could not extract username from URL: %s", err)
+			c.Gig().logger().Debug("could not extract username from URL", "error", err)
 			return c.NoContent(StatusBadRequest, "Invalid username received")
 		}
 
@@ -62,7 +176,7 @@ func (g *Gig) PassAuthLoginHandle(path string, fn PassAuthLogin) {
 	g.Handle(path+"/:username", func(c Context) error {
 		var (
 			username = c.Param("username")
-			sig      = c.CertHash()
+			sig      = c.CertFingerprint(config.Fingerprint)
 		)
 
 		if sig == "" {
@@ -76,7 +190,7 @@ func (g *Gig) PassAuthLoginHandle(path string, fn PassAuthLogin) {
 		password, err := c.QueryString()
 
 		if err != nil {
-			debugPrintf("gemini: could not extract password from URL: %s", err)
+			c.Gig().logger().Debug("could not extract password from URL", "error", err)
 			return c.NoContent(StatusBadRequest, "Invalid password received")
 		}
 
@@ -84,12 +198,115 @@ func (g *Gig) PassAuthLoginHandle(path string, fn PassAuthLogin) {
 			return c.NoContent(StatusSensitiveInput, "Enter password")
 		}
 
-		to, err := fn(username, password, sig, c)
+		if locked, err := config.Limiter.slowDown(username, sig, c); locked {
+			return err
+		}
+
+		defer config.Limiter.release(username, sig, c.IP())
+
+		if config.TOTP != nil {
+			secret, err := config.totpSecret(username, password)
+			if err != nil {
+				config.Limiter.Fail(username, sig, err, c)
+				return err
+			}
+
+			if secret != "" {
+				prompts.set(sig, username, password)
+				return c.NoContent(StatusRedirectTemporary, "%s/%s/otp", path, username)
+			}
+		}
+
+		to, err := config.Login(username, password, sig, c)
 
 		if err != nil {
+			config.Limiter.Fail(username, sig, err, c)
 			return err
 		}
 
+		config.Limiter.Succeed(username, sig)
+
 		return c.NoContent(StatusRedirectTemporary, to)
 	})
+
+	if config.TOTP == nil {
+		return
+	}
+
+	guard := newTOTPGuard()
+
+	g.Handle(path+"/:username/otp", func(c Context) error {
+		var (
+			username = c.Param("username")
+			sig      = c.CertFingerprint(config.Fingerprint)
+		)
+
+		if sig == "" {
+			return c.NoContent(StatusClientCertificateRequired, "Please create a certificate")
+		}
+
+		code, err := c.QueryString()
+		if err != nil {
+			return c.NoContent(StatusBadRequest, "Invalid code received")
+		}
+
+		if code == "" {
+			return c.NoContent(StatusSensitiveInput, "Enter code from authenticator app or a recovery code")
+		}
+
+		p := prompts.take(sig)
+		if p == nil || p.username != username {
+			return c.NoContent(StatusRedirectTemporary, "%s/%s", path, username)
+		}
+
+		if locked, err := config.Limiter.slowDown(username, sig, c); locked {
+			prompts.put(sig, p)
+			return err
+		}
+
+		defer config.Limiter.release(username, sig, c.IP())
+
+		secret, err := config.TOTP.TOTPSecret(username)
+		if err != nil {
+			return err
+		}
+
+		ok, err := verifyTOTP(config.TOTP, guard, username, secret, code)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			config.Limiter.Fail(username, sig, ErrInvalidCredentials, c)
+
+			if !prompts.retry(sig, p) {
+				return c.NoContent(StatusRedirectTemporary, "%s/%s", path, username)
+			}
+
+			return c.NoContent(StatusSensitiveInput, "Invalid code, try again")
+		}
+
+		to, err := config.Login(username, p.password, sig, c)
+		if err != nil {
+			config.Limiter.Fail(username, sig, err, c)
+			return err
+		}
+
+		config.Limiter.Succeed(username, sig)
+
+		return c.NoContent(StatusRedirectTemporary, to)
+	})
+}
+
+// totpSecret authenticates user and returns their TOTP secret.
+func (config *PassAuthLoginConfig) totpSecret(username, password string) (string, error) {
+	if err := config.TOTP.Authenticate(username, password); err != nil {
+		if err == ErrInvalidCredentials {
+			return "", NewErrorFrom(ErrCertificateNotAuthorised, "Invalid username or password")
+		}
+
+		return "", err
+	}
+
+	return config.TOTP.TOTPSecret(username)
 }
@@ -0,0 +1,332 @@
+package gig
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+type (
+	// PassAuthLimiterConfig defines the config for PassAuthLimiter.
+	PassAuthLimiterConfig struct {
+		// UsernameFailures is the number of failed logins for a username
+		// before it is locked out. Negative value disables the limit.
+		// Optional. Default value 5.
+		UsernameFailures int
+
+		// CertFailures is the number of failed logins using a certificate
+		// before it is locked out. Negative value disables the limit.
+		// Optional. Default value 5.
+		CertFailures int
+
+		// IPFailures is the number of failed logins from an IP address before
+		// it is locked out. Negative value disables the limit.
+		// Optional. Default value 20.
+		IPFailures int
+
+		// Backoff is how long the first lockout lasts. It doubles with every
+		// further failure.
+		// Optional. Default value 1 minute.
+		Backoff time.Duration
+
+		// MaxBackoff caps lockout duration.
+		// Optional. Default value 1 hour.
+		MaxBackoff time.Duration
+
+		// Forget resets failures of a username, certificate or IP address
+		// after this long without failures.
+		// Optional. Default value 24 hours.
+		Forget time.Duration
+
+		// OnFailure is called after every failed login with username and
+		// error returned by login.
+		// Optional.
+		OnFailure func(username string, err error, c Context)
+
+		// OnLockout is called when username, certificate or IP address is
+		// locked out, with key such as "ip:192.0.2.1", until the lockout
+		// ends.
+		// Optional.
+		OnLockout func(key string, until time.Time, c Context)
+	}
+
+	// PassAuthLimiter limits failed logins per username, certificate and IP
+	// address. It is safe for concurrent use.
+	PassAuthLimiter struct {
+		config  PassAuthLimiterConfig
+		mu      sync.Mutex
+		entries map[string]*limiterEntry
+	}
+
+	limiterEntry struct {
+		failures int
+		pending  int // Attempts in progress
+		last     time.Time
+		until    time.Time
+	}
+)
+
+var (
+	// DefaultPassAuthLimiterConfig is the default PassAuthLimiter config.
+	DefaultPassAuthLimiterConfig = PassAuthLimiterConfig{
+		UsernameFailures: 5,
+		CertFailures:     5,
+		IPFailures:       20,
+		Backoff:          time.Minute,
+		MaxBackoff:       time.Hour,
+		Forget:           24 * time.Hour,
+	}
+)
+
+// NewPassAuthLimiter returns a PassAuthLimiter with config. Set it as
+// `PassAuthLoginConfig.Limiter` to make login handlers answer locked out
+// attempts with 44 SLOW DOWN.
+//
+// Note that limiting by username lets anyone lock a user out for a while.
+func NewPassAuthLimiter(config PassAuthLimiterConfig) *PassAuthLimiter {
+	// Defaults
+	if config.UsernameFailures == 0 {
+		config.UsernameFailures = DefaultPassAuthLimiterConfig.UsernameFailures
+	}
+
+	if config.CertFailures == 0 {
+		config.CertFailures = DefaultPassAuthLimiterConfig.CertFailures
+	}
+
+	if config.IPFailures == 0 {
+		config.IPFailures = DefaultPassAuthLimiterConfig.IPFailures
+	}
+
+	if config.Backoff == 0 {
+		config.Backoff = DefaultPassAuthLimiterConfig.Backoff
+	}
+
+	if config.MaxBackoff == 0 {
+		config.MaxBackoff = DefaultPassAuthLimiterConfig.MaxBackoff
+	}
+
+	if config.Forget == 0 {
+		config.Forget = DefaultPassAuthLimiterConfig.Forget
+	}
+
+	return &PassAuthLimiter{
+		config:  config,
+		entries: map[string]*limiterEntry{},
+	}
+}
+
+// Wait returns how long login attempts for username using certificate sig
+// from ip are locked out, or 0.
+func (l *PassAuthLimiter) Wait(username, sig, ip string) time.Duration {
+	if l == nil {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var (
+		now  = time.Now()
+		wait time.Duration
+	)
+
+	for _, key := range limiterKeys(username, sig, ip) {
+		if e := l.entries[key]; e != nil {
+			if d := e.until.Sub(now); d > wait {
+				wait = d
+			}
+		}
+	}
+
+	return wait
+}
+
+// Fail records failed login, calling audit hooks.
+func (l *PassAuthLimiter) Fail(username, sig string, err error, c Context) {
+	if l == nil {
+		return
+	}
+
+	if l.config.OnFailure != nil {
+		l.config.OnFailure(username, err, c)
+	}
+
+	var (
+		now    = time.Now()
+		keys   = limiterKeys(username, sig, c.IP())
+		limits = l.limits()
+		locked = map[string]time.Time{}
+	)
+
+	l.mu.Lock()
+
+	for key, e := range l.entries {
+		if e.pending == 0 && now.Sub(e.last) > l.config.Forget && now.After(e.until) {
+			delete(l.entries, key)
+		}
+	}
+
+	for i, key := range keys {
+		if limits[i] < 0 {
+			continue
+		}
+
+		e := l.entries[key]
+		if e == nil {
+			e = &limiterEntry{}
+			l.entries[key] = e
+		}
+
+		e.failures++
+		e.last = now
+
+		if over := e.failures - limits[i]; over >= 0 {
+			e.until = now.Add(l.backoff(over))
+			locked[key] = e.until
+		}
+	}
+
+	l.mu.Unlock()
+
+	if l.config.OnLockout != nil {
+		for _, key := range keys {
+			if until, ok := locked[key]; ok {
+				l.config.OnLockout(key, until, c)
+			}
+		}
+	}
+}
+
+// Succeed forgets failures of username and certificate sig after successful
+// login.
+func (l *PassAuthLimiter) Succeed(username, sig string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.entries, "user:"+username)
+	delete(l.entries, "cert:"+sig)
+}
+
+// reserve counts login attempt as in progress unless username, certificate
+// sig or ip is locked out, in which case it returns how long to wait. Checking
+// and counting at once keeps concurrent attempts within limits. Attempts that
+// would exceed a limit if all in progress ones fail wait a second for them to
+// finish. Reserved attempts must be released.
+func (l *PassAuthLimiter) reserve(username, sig, ip string) time.Duration {
+	if l == nil {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var (
+		now    = time.Now()
+		keys   = limiterKeys(username, sig, ip)
+		limits = l.limits()
+		wait   time.Duration
+	)
+
+	for i, key := range keys {
+		e := l.entries[key]
+		if e == nil || limits[i] < 0 {
+			continue
+		}
+
+		if d := e.until.Sub(now); d > wait {
+			wait = d
+		}
+
+		if e.pending >= e.allowed(limits[i]) && wait < time.Second {
+			wait = time.Second
+		}
+	}
+
+	if wait > 0 {
+		return wait
+	}
+
+	for i, key := range keys {
+		if limits[i] < 0 {
+			continue
+		}
+
+		e := l.entries[key]
+		if e == nil {
+			e = &limiterEntry{}
+			l.entries[key] = e
+		}
+
+		e.pending++
+		e.last = now
+	}
+
+	return 0
+}
+
+// release ends login attempt counted by reserve. Call it after Fail or
+// Succeed, or when attempt ended otherwise.
+func (l *PassAuthLimiter) release(username, sig, ip string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range limiterKeys(username, sig, ip) {
+		e := l.entries[key]
+		if e == nil || e.pending == 0 {
+			continue
+		}
+
+		e.pending--
+
+		if e.pending == 0 && e.failures == 0 {
+			delete(l.entries, key)
+		}
+	}
+}
+
+// allowed returns how many attempts may be in progress at once before limit
+// is reached. After lockout ends, one attempt at a time is allowed.
+func (e *limiterEntry) allowed(limit int) int {
+	if e.failures < limit {
+		return limit - e.failures
+	}
+
+	return 1
+}
+
+func (l *PassAuthLimiter) limits() []int {
+	return []int{l.config.UsernameFailures, l.config.CertFailures, l.config.IPFailures}
+}
+
+// backoff returns lockout duration after over failures above limit.
+func (l *PassAuthLimiter) backoff(over int) time.Duration {
+	d := float64(l.config.Backoff) * math.Pow(2, float64(over))
+	if d > float64(l.config.MaxBackoff) {
+		return l.config.MaxBackoff
+	}
+
+	return time.Duration(d)
+}
+
+func limiterKeys(username, sig, ip string) []string {
+	return []string{"user:" + username, "cert:" + sig, "ip:" + ip}
+}
+
+// slowDown reserves login attempt, or responds with 44 and seconds to wait if
+// login is locked out. Reserved attempt must be released, see `release()`.
+func (l *PassAuthLimiter) slowDown(username, sig string, c Context) (bool, error) {
+	wait := l.reserve(username, sig, c.IP())
+	if wait <= 0 {
+		return false, nil
+	}
+
+	return true, c.NoContent(StatusSlowDown, "%d", int(math.Ceil(wait.Seconds())))
+}
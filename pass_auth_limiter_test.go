@@ -0,0 +1,214 @@
+package gig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPassAuthLimiter(t *testing.T) {
+	var (
+		is       = is.New(t)
+		g        = New()
+		cert     = &x509.Certificate{Raw: []byte{1}}
+		other    = &x509.Certificate{Raw: []byte{2}}
+		failures []string
+		lockouts []string
+	)
+
+	limiter := NewPassAuthLimiter(PassAuthLimiterConfig{
+		UsernameFailures: 2,
+		CertFailures:     3,
+		IPFailures:       -1,
+		OnFailure: func(username string, err error, c Context) {
+			failures = append(failures, username)
+		},
+		OnLockout: func(key string, until time.Time, c Context) {
+			lockouts = append(lockouts, key)
+		},
+	})
+
+	g.PassAuthLoginHandleWithConfig("/login", PassAuthLoginConfig{
+		Login: func(username, password, sig string, c Context) (string, error) {
+			if password != "secret" {
+				return "", NewErrorFrom(ErrCertificateNotAuthorised, "Invalid username or password")
+			}
+
+			return "/", nil
+		},
+		Limiter: limiter,
+	})
+
+	req := func(path string, cert *x509.Certificate) string {
+		c, conn := g.NewFakeContext(path, &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}})
+		g.ServeGemini(c)
+
+		return conn.Written
+	}
+
+	sig := CertFingerprint(cert, FingerprintSHA256)
+
+	is.Equal("61 Invalid username or password\r\n", req("/login/jon?wrong", cert))
+	is.Equal("61 Invalid username or password\r\n", req("/login/jon?wrong", cert))
+	is.Equal([]string{"jon", "jon"}, failures)
+	is.Equal([]string{"user:jon"}, lockouts)
+
+	// Username is locked out, even with correct password
+	is.Equal("44 60\r\n", req("/login/jon?secret", cert))
+	is.Equal("44 60\r\n", req("/login/jon?secret", other))
+
+	// Certificate is locked out after third failure
+	is.Equal("61 Invalid username or password\r\n", req("/login/arya?wrong", cert))
+	is.Equal([]string{"user:jon", "cert:" + sig}, lockouts)
+	is.Equal("44 60\r\n", req("/login/arya?secret", cert))
+	is.Equal("30 /\r\n", req("/login/arya?secret", other))
+
+	// Backoff doubles with every failure and is capped
+	is.Equal(time.Minute, limiter.backoff(0))
+	is.Equal(4*time.Minute, limiter.backoff(2))
+	is.Equal(time.Hour, limiter.backoff(10))
+
+	// Success forgets failures
+	limiter.Succeed("jon", sig)
+	is.Equal(time.Duration(0), limiter.Wait("jon", sig, "192.0.2.1"))
+
+	// Nil limiter never locks out
+	var none *PassAuthLimiter
+	none.Fail("jon", sig, errors.New("oops"), nil)
+	is.Equal(time.Duration(0), none.Wait("jon", sig, "192.0.2.1"))
+}
+
+func TestPassAuthLimiter_Concurrent(t *testing.T) {
+	var (
+		is      = is.New(t)
+		g       = New()
+		cert    = &x509.Certificate{Raw: []byte{1}}
+		entered = make(chan struct{})
+		done    = make(chan struct{})
+		written = make(chan string)
+	)
+
+	g.PassAuthLoginHandleWithConfig("/login", PassAuthLoginConfig{
+		Login: func(username, password, sig string, c Context) (string, error) {
+			entered <- struct{}{}
+			<-done
+
+			return "", NewErrorFrom(ErrCertificateNotAuthorised, "Invalid username or password")
+		},
+		Limiter: NewPassAuthLimiter(PassAuthLimiterConfig{
+			UsernameFailures: 3,
+			CertFailures:     -1,
+			IPFailures:       -1,
+		}),
+	})
+
+	for i := 0; i < 10; i++ {
+		go func() {
+			c, conn := g.NewFakeContext("/login/jon?wrong", &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}})
+			g.ServeGemini(c)
+			written <- conn.Written
+		}()
+	}
+
+	// Only as many attempts as may fail run at once, others are asked to
+	// wait for them
+	tries := 0
+
+	for i := 0; i < 10; i++ {
+		select {
+		case <-entered:
+			tries++
+		case b := <-written:
+			is.Equal("44 1\r\n", b)
+		}
+	}
+
+	close(done)
+
+	for i := 0; i < tries; i++ {
+		is.Equal("61 Invalid username or password\r\n", <-written)
+	}
+
+	is.Equal(3, tries)
+
+	c, conn := g.NewFakeContext("/login/jon?wrong", &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}})
+	g.ServeGemini(c)
+	is.Equal("44 60\r\n", conn.Written)
+}
+
+func TestPassAuthLimiter_IP(t *testing.T) {
+	var (
+		is      = is.New(t)
+		g       = New()
+		limiter = NewPassAuthLimiter(PassAuthLimiterConfig{
+			UsernameFailures: -1,
+			CertFailures:     -1,
+			IPFailures:       1,
+			Backoff:          time.Second,
+		})
+		c, _ = g.NewFakeContext("/", nil)
+	)
+
+	limiter.Fail("jon", "a", ErrInvalidCredentials, c)
+
+	wait := limiter.Wait("arya", "b", "192.0.2.1")
+	is.True(wait > 0 && wait <= time.Second)
+	is.Equal(time.Duration(0), limiter.Wait("arya", "b", "192.0.2.2"))
+}
+
+func TestPassAuthLimiter_Account(t *testing.T) {
+	var (
+		is      = is.New(t)
+		g       = New()
+		store   = NewPassAuthMemoryStore()
+		cert    = &x509.Certificate{Raw: []byte{1}}
+		sig     = CertFingerprint(cert, FingerprintSHA256)
+		limiter = NewPassAuthLimiter(PassAuthLimiterConfig{
+			UsernameFailures: 2,
+			CertFailures:     -1,
+			IPFailures:       -1,
+		})
+		config = PassAuthAccountConfig{Store: store, Limiter: limiter}
+	)
+
+	store.Cost = bcrypt.MinCost
+	is.NoErr(store.Register("jon", "winteriscoming"))
+	is.NoErr(store.Pin("jon", sig))
+
+	g.PassAuthPasswordHandle("/password", config)
+	g.PassAuthTOTPHandle("/2fa", config)
+
+	req := func(path string) string {
+		c, conn := g.NewFakeContext(path, &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}})
+		g.ServeGemini(c)
+
+		return conn.Written
+	}
+
+	// Current password
+	is.Equal("11 Wrong password, enter current password\r\n", req("/password?wrong"))
+	is.Equal("11 Wrong password, enter current password\r\n", req("/password?wrong"))
+	is.Equal("44 60\r\n", req("/password?winteriscoming"))
+
+	limiter.Succeed("jon", sig)
+	is.Equal("30 /password/new\r\n", req("/password?winteriscoming"))
+
+	// Disabling TOTP
+	is.NoErr(store.SetTOTPSecret("jon", NewTOTPSecret()))
+	is.Equal("10 Invalid code, try again\r\n", req("/2fa/disable?abcdef"))
+	is.Equal("10 Invalid code, try again\r\n", req("/2fa/disable?abcdef"))
+	is.Equal("44 60\r\n", req("/2fa/disable?abcdef"))
+
+	// Confirming TOTP enrolment
+	limiter.Succeed("jon", sig)
+	is.NoErr(store.SetTOTPSecret("jon", ""))
+	req("/2fa")
+	is.Equal("10 Invalid code, try again\r\n", req("/2fa/verify?abcdef"))
+	is.Equal("10 Invalid code, try again\r\n", req("/2fa/verify?abcdef"))
+	is.Equal("44 60\r\n", req("/2fa/verify?abcdef"))
+}
@@ -0,0 +1,649 @@
+package gig
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+type (
+	// PassAuthStore persists users, their passwords and certificates pinned
+	// to them. Implementations must be safe for concurrent use.
+	PassAuthStore interface {
+		// Register creates user with password, or returns ErrUserExists.
+		Register(username, password string) error
+
+		// Authenticate returns ErrInvalidCredentials unless password is
+		// valid for username.
+		Authenticate(username, password string) error
+
+		// SetPassword replaces password of username.
+		SetPassword(username, password string) error
+
+		// Pin links certificate fingerprint to username.
+		Pin(username, sig string) error
+
+		// Unpin removes certificate fingerprint from its user.
+		Unpin(sig string) error
+
+		// User returns username certificate fingerprint is pinned to, or empty
+		// string if none.
+		User(sig string) (string, error)
+
+		// Certificates returns fingerprints pinned to username.
+		Certificates(username string) ([]string, error)
+	}
+
+	// PassAuthAccountConfig defines the config for account handlers, such as
+	// `Gig#PassAuthRegisterHandle()`.
+	PassAuthAccountConfig struct {
+		// Store persists users.
+		// Required.
+		Store PassAuthStore
+
+		// Fingerprint defines how certificate fingerprint is computed. It
+		// must match fingerprint used by PassAuth middleware.
+		// Optional. Default value FingerprintSHA256.
+		Fingerprint FingerprintAlgorithm
+
+		// Redirect is the path users are sent to after registration or
+		// logout.
+		// Optional. Default value "/".
+		Redirect string
+
+		// MinPasswordLength is the minimum length of new passwords.
+		// Optional. Default value 8.
+		MinPasswordLength int
+
+		// Limiter locks out username, certificate and IP address after wrong
+		// passwords or codes are entered to change password or TOTP
+		// settings. Use the same limiter as `PassAuthLoginConfig.Limiter`
+		// to count all failures together.
+		// Optional.
+		Limiter *PassAuthLimiter
+	}
+
+	// PassAuthMemoryStore is a PassAuthTOTPStore that keeps users in memory
+	// and hashes passwords using bcrypt.
+	PassAuthMemoryStore struct {
+		// Cost is bcrypt cost of new password hashes.
+		Cost int
+
+		mu       sync.RWMutex
+		users    map[string][]byte          // Username to password hash
+		pins     map[string]string          // Fingerprint to username
+		totp     map[string]string          // Username to TOTP secret
+		recovery map[string]map[string]bool // Username to recovery code hashes
+
+		dummyOnce sync.Once
+		dummy     []byte // Hash compared for unknown users
+	}
+
+	// passAuthPending holds input of multi step prompts until it is
+	// confirmed.
+	passAuthPending struct {
+		username string
+		password string
+		expires  time.Time
+		failures int
+	}
+
+	passAuthPrompts struct {
+		mu      sync.Mutex
+		pending map[string]*passAuthPending // By fingerprint
+	}
+)
+
+// passAuthPromptAttempts is the number of wrong codes accepted by a pending
+// prompt before it is dropped and user has to start over.
+const passAuthPromptAttempts = 3
+
+var (
+	// DefaultPassAuthAccountConfig is the default account handlers config.
+	DefaultPassAuthAccountConfig = PassAuthAccountConfig{
+		Fingerprint:       FingerprintSHA256,
+		Redirect:          "/",
+		MinPasswordLength: 8,
+	}
+
+	// ErrUserExists is returned when registering a username that is taken.
+	ErrUserExists = errors.New("user already exists")
+	// ErrInvalidCredentials is returned when username or password is wrong.
+	ErrInvalidCredentials = errors.New("invalid username or password")
+)
+
+// PassAuthStoreCheck returns a PassAuthCertCheck that redirects to login
+// unless certificate is pinned to a user in store.
+func PassAuthStoreCheck(store PassAuthStore, login string) PassAuthCertCheck {
+	return func(sig string, c Context) (string, error) {
+		user, err := store.User(sig)
+		if err != nil {
+			return "", err
+		}
+
+		if user == "" {
+			return login, nil
+		}
+
+		return "", nil
+	}
+}
+
+// PassAuthStoreLogin returns a PassAuthLogin that authenticates user against
+// store, pins certificate and redirects to path to.
+func PassAuthStoreLogin(store PassAuthStore, to string) PassAuthLogin {
+	return func(username, password, sig string, c Context) (string, error) {
+		if err := store.Authenticate(username, password); err != nil {
+			if err == ErrInvalidCredentials {
+				return "", NewErrorFrom(ErrCertificateNotAuthorised, "Invalid username or password")
+			}
+
+			return "", err
+		}
+
+		if err := store.Pin(username, sig); err != nil {
+			return "", err
+		}
+
+		return to, nil
+	}
+}
+
+// PassAuthRegisterHandle sets up handlers to register a new user. Username,
+// password and password confirmation are prompted for in turn, and
+// certificate is pinned to the new user.
+func (g *Gig) PassAuthRegisterHandle(path string, config PassAuthAccountConfig) {
+	config.defaults()
+
+	prompts := newPassAuthPrompts()
+
+	g.Handle(path, func(c Context) error {
+		if c.Certificate() == nil {
+			return c.NoContent(StatusClientCertificateRequired, "Please create a certificate")
+		}
+
+		username, err := c.QueryString()
+		if err != nil {
+			return c.NoContent(StatusBadRequest, "Invalid username received")
+		}
+
+		if username == "" {
+			return c.NoContent(StatusInput, "Enter username")
+		}
+
+		return c.NoContent(StatusRedirectTemporary, "%s/%s", path, url.PathEscape(username))
+	})
+
+	g.Handle(path+"/:username", func(c Context) error {
+		sig := c.CertFingerprint(config.Fingerprint)
+		if sig == "" {
+			return c.NoContent(StatusClientCertificateRequired, "Please create a certificate")
+		}
+
+		username, err := url.PathUnescape(c.Param("username"))
+		if err != nil {
+			return c.NoContent(StatusBadRequest, "Invalid username received")
+		}
+
+		password, err := c.QueryString()
+		if err != nil {
+			return c.NoContent(StatusBadRequest, "Invalid password received")
+		}
+
+		if password == "" {
+			return c.NoContent(StatusSensitiveInput, "Enter password")
+		}
+
+		if len(password) < config.MinPasswordLength {
+			return c.NoContent(StatusSensitiveInput, "Password is too short, enter at least %d characters", config.MinPasswordLength)
+		}
+
+		prompts.set(sig, username, password)
+
+		return c.NoContent(StatusRedirectTemporary, "%s/%s/confirm", path, url.PathEscape(username))
+	})
+
+	g.Handle(path+"/:username/confirm", func(c Context) error {
+		sig := c.CertFingerprint(config.Fingerprint)
+		if sig == "" {
+			return c.NoContent(StatusClientCertificateRequired, "Please create a certificate")
+		}
+
+		password, err := c.QueryString()
+		if err != nil {
+			return c.NoContent(StatusBadRequest, "Invalid password received")
+		}
+
+		if password == "" {
+			return c.NoContent(StatusSensitiveInput, "Confirm password")
+		}
+
+		username, err := url.PathUnescape(c.Param("username"))
+		if err != nil {
+			return c.NoContent(StatusBadRequest, "Invalid username received")
+		}
+
+		p := prompts.take(sig)
+		if p == nil || p.username != username {
+			return c.NoContent(StatusRedirectTemporary, "%s", path)
+		}
+
+		if p.password != password {
+			return c.NoContent(StatusRedirectTemporary, "%s/%s", path, url.PathEscape(username))
+		}
+
+		if err := config.Store.Register(p.username, p.password); err != nil {
+			if err == ErrUserExists {
+				return c.Gemini("# Username is taken\n\n=> %s Try another username", path)
+			}
+
+			return err
+		}
+
+		if err := config.Store.Pin(p.username, sig); err != nil {
+			return err
+		}
+
+		return c.NoContent(StatusRedirectTemporary, "%s", config.Redirect)
+	})
+}
+
+// PassAuthLogoutHandle sets up handler that unpins certificate from its user.
+func (g *Gig) PassAuthLogoutHandle(path string, config PassAuthAccountConfig) {
+	config.defaults()
+
+	g.Handle(path, func(c Context) error {
+		sig := c.CertFingerprint(config.Fingerprint)
+		if sig == "" {
+			return c.NoContent(StatusClientCertificateRequired, "Please create a certificate")
+		}
+
+		if err := config.Store.Unpin(sig); err != nil {
+			return err
+		}
+
+		return c.NoContent(StatusRedirectTemporary, "%s", config.Redirect)
+	})
+}
+
+// PassAuthPasswordHandle sets up handlers to change password of user that
+// certificate is pinned to. Current password, new password and its
+// confirmation are prompted for in turn.
+func (g *Gig) PassAuthPasswordHandle(path string, config PassAuthAccountConfig) {
+	config.defaults()
+
+	prompts := newPassAuthPrompts()
+
+	g.Handle(path, func(c Context) error {
+		sig, username, err := config.user(c)
+		if sig == "" || err != nil {
+			return err
+		}
+
+		password, err := c.QueryString()
+		if err != nil {
+			return c.NoContent(StatusBadRequest, "Invalid password received")
+		}
+
+		if password == "" {
+			return c.NoContent(StatusSensitiveInput, "Enter current password")
+		}
+
+		if locked, err := config.Limiter.slowDown(username, sig, c); locked {
+			return err
+		}
+
+		defer config.Limiter.release(username, sig, c.IP())
+
+		if err := config.Store.Authenticate(username, password); err != nil {
+			if err == ErrInvalidCredentials {
+				config.Limiter.Fail(username, sig, err, c)
+				return c.NoContent(StatusSensitiveInput, "Wrong password, enter current password")
+			}
+
+			return err
+		}
+
+		config.Limiter.Succeed(username, sig)
+		prompts.set(sig, username, "")
+
+		return c.NoContent(StatusRedirectTemporary, "%s", path+"/new")
+	})
+
+	g.Handle(path+"/new", func(c Context) error {
+		sig, username, err := config.user(c)
+		if sig == "" || err != nil {
+			return err
+		}
+
+		password, err := c.QueryString()
+		if err != nil {
+			return c.NoContent(StatusBadRequest, "Invalid password received")
+		}
+
+		if password == "" {
+			return c.NoContent(StatusSensitiveInput, "Enter new password")
+		}
+
+		if len(password) < config.MinPasswordLength {
+			return c.NoContent(StatusSensitiveInput, "Password is too short, enter at least %d characters", config.MinPasswordLength)
+		}
+
+		if p := prompts.take(sig); p == nil || p.username != username {
+			return c.NoContent(StatusRedirectTemporary, "%s", path)
+		}
+
+		prompts.set(sig, username, password)
+
+		return c.NoContent(StatusRedirectTemporary, "%s", path+"/confirm")
+	})
+
+	g.Handle(path+"/confirm", func(c Context) error {
+		sig, username, err := config.user(c)
+		if sig == "" || err != nil {
+			return err
+		}
+
+		password, err := c.QueryString()
+		if err != nil {
+			return c.NoContent(StatusBadRequest, "Invalid password received")
+		}
+
+		if password == "" {
+			return c.NoContent(StatusSensitiveInput, "Confirm new password")
+		}
+
+		p := prompts.take(sig)
+		if p == nil || p.username != username || p.password == "" {
+			return c.NoContent(StatusRedirectTemporary, "%s", path)
+		}
+
+		if p.password != password {
+			prompts.set(sig, username, "")
+			return c.NoContent(StatusRedirectTemporary, "%s", path+"/new")
+		}
+
+		if err := config.Store.SetPassword(username, password); err != nil {
+			return err
+		}
+
+		return c.Gemini("# Password changed")
+	})
+}
+
+// PassAuthCertsHandle sets up handlers to list certificates pinned to user
+// and to revoke them.
+func (g *Gig) PassAuthCertsHandle(path string, config PassAuthAccountConfig) {
+	config.defaults()
+
+	g.Handle(path, func(c Context) error {
+		sig, username, err := config.user(c)
+		if sig == "" || err != nil {
+			return err
+		}
+
+		sigs, err := config.Store.Certificates(username)
+		if err != nil {
+			return err
+		}
+
+		text := "# Certificates of " + username + "\n\n"
+
+		for _, s := range sigs {
+			if s == sig {
+				text += fmt.Sprintf("* %s (this certificate)\n", s)
+				continue
+			}
+
+			text += fmt.Sprintf("=> %s/revoke/%s Revoke %s\n", path, s, s)
+		}
+
+		return c.GeminiBlob([]byte(text))
+	})
+
+	g.Handle(path+"/revoke/:sig", func(c Context) error {
+		sig, username, err := config.user(c)
+		if sig == "" || err != nil {
+			return err
+		}
+
+		sigs, err := config.Store.Certificates(username)
+		if err != nil {
+			return err
+		}
+
+		if revoke := c.Param("sig"); containsString(sigs, revoke) {
+			if err := config.Store.Unpin(revoke); err != nil {
+				return err
+			}
+		}
+
+		return c.NoContent(StatusRedirectTemporary, "%s", path)
+	})
+}
+
+func (config *PassAuthAccountConfig) defaults() {
+	if config.Store == nil {
+		panic("gig: pass auth account handler requires a store")
+	}
+
+	if config.Redirect == "" {
+		config.Redirect = DefaultPassAuthAccountConfig.Redirect
+	}
+
+	if config.MinPasswordLength == 0 {
+		config.MinPasswordLength = DefaultPassAuthAccountConfig.MinPasswordLength
+	}
+}
+
+// user returns certificate fingerprint and user it is pinned to. If there is
+// none, response is sent and empty fingerprint is returned.
+func (config *PassAuthAccountConfig) user(c Context) (string, string, error) {
+	sig := c.CertFingerprint(config.Fingerprint)
+	if sig == "" {
+		return "", "", c.NoContent(StatusClientCertificateRequired, "Please create a certificate")
+	}
+
+	username, err := config.Store.User(sig)
+	if err != nil {
+		return "", "", err
+	}
+
+	if username == "" {
+		return "", "", c.NoContent(StatusCertificateNotAuthorised, "Please login first")
+	}
+
+	return sig, username, nil
+}
+
+func newPassAuthPrompts() *passAuthPrompts {
+	return &passAuthPrompts{pending: map[string]*passAuthPending{}}
+}
+
+func (p *passAuthPrompts) set(sig, username, password string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+
+	for k, v := range p.pending {
+		if now.After(v.expires) {
+			delete(p.pending, k)
+		}
+	}
+
+	p.pending[sig] = &passAuthPending{
+		username: username,
+		password: password,
+		expires:  now.Add(10 * time.Minute),
+	}
+}
+
+// put restores pending input taken from prompt without counting a failure.
+func (p *passAuthPrompts) put(sig string, v *passAuthPending) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.pending[sig] = v
+}
+
+// retry restores pending input after a wrong code, unless it ran out of
+// attempts. It returns whether user may try again.
+func (p *passAuthPrompts) retry(sig string, v *passAuthPending) bool {
+	v.failures++
+	if v.failures >= passAuthPromptAttempts {
+		return false
+	}
+
+	p.put(sig, v)
+
+	return true
+}
+
+func (p *passAuthPrompts) take(sig string) *passAuthPending {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	v := p.pending[sig]
+	delete(p.pending, sig)
+
+	if v == nil || time.Now().After(v.expires) {
+		return nil
+	}
+
+	return v
+}
+
+// NewPassAuthMemoryStore returns an empty PassAuthMemoryStore.
+func NewPassAuthMemoryStore() *PassAuthMemoryStore {
+	return &PassAuthMemoryStore{
+		Cost:     bcrypt.DefaultCost,
+		users:    map[string][]byte{},
+		pins:     map[string]string{},
+		totp:     map[string]string{},
+		recovery: map[string]map[string]bool{},
+	}
+}
+
+// Register implements PassAuthStore.
+func (s *PassAuthMemoryStore) Register(username, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), s.Cost)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[username]; ok {
+		return ErrUserExists
+	}
+
+	s.users[username] = hash
+
+	return nil
+}
+
+// Authenticate implements PassAuthStore.
+func (s *PassAuthMemoryStore) Authenticate(username, password string) error {
+	s.mu.RLock()
+	hash, ok := s.users[username]
+	s.mu.RUnlock()
+
+	if !ok {
+		// Take as long as for existing users, so that usernames cannot be
+		// probed by timing
+		_ = bcrypt.CompareHashAndPassword(s.dummyHash(), []byte(password))
+
+		return ErrInvalidCredentials
+	}
+
+	if bcrypt.CompareHashAndPassword(hash, []byte(password)) != nil {
+		return ErrInvalidCredentials
+	}
+
+	return nil
+}
+
+// dummyHash returns hash of a random password at store's cost.
+func (s *PassAuthMemoryStore) dummyHash() []byte {
+	s.dummyOnce.Do(func() {
+		s.dummy, _ = bcrypt.GenerateFromPassword([]byte(randomID()), s.Cost)
+	})
+
+	return s.dummy
+}
+
+// SetPassword implements PassAuthStore.
+func (s *PassAuthMemoryStore) SetPassword(username, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), s.Cost)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[username]; !ok {
+		return ErrInvalidCredentials
+	}
+
+	s.users[username] = hash
+
+	return nil
+}
+
+// Pin implements PassAuthStore.
+func (s *PassAuthMemoryStore) Pin(username, sig string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[username]; !ok {
+		return ErrInvalidCredentials
+	}
+
+	s.pins[sig] = username
+
+	return nil
+}
+
+// Unpin implements PassAuthStore.
+func (s *PassAuthMemoryStore) Unpin(sig string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.pins, sig)
+
+	return nil
+}
+
+// User implements PassAuthStore.
+func (s *PassAuthMemoryStore) User(sig string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.pins[sig], nil
+}
+
+// Certificates implements PassAuthStore.
+func (s *PassAuthMemoryStore) Certificates(username string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var sigs []string
+
+	for sig, u := range s.pins {
+		if u == username {
+			sigs = append(sigs, sig)
+		}
+	}
+
+	sort.Strings(sigs)
+
+	return sigs, nil
+}
@@ -0,0 +1,102 @@
+package gig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+
+	"github.com/matryer/is"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPassAuthAccount(t *testing.T) {
+	var (
+		is     = is.New(t)
+		g      = New()
+		store  = NewPassAuthMemoryStore()
+		laptop = &x509.Certificate{Raw: []byte{1}}
+		phone  = &x509.Certificate{Raw: []byte{2}}
+		config = PassAuthAccountConfig{Store: store, Redirect: "/private"}
+		req    = func(path string, cert *x509.Certificate) string {
+			var state *tls.ConnectionState
+			if cert != nil {
+				state = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+			}
+
+			c, res := g.NewFakeContext(path, state)
+			g.ServeGemini(c)
+
+			return res.Written
+		}
+		laptopSig = CertFingerprint(laptop, FingerprintSHA256)
+		phoneSig  = CertFingerprint(phone, FingerprintSHA256)
+	)
+
+	store.Cost = bcrypt.MinCost
+
+	g.Handle("/private", func(c Context) error {
+		user, _ := store.User(c.CertFingerprint(FingerprintSHA256))
+		return c.Gemini("hello %s", user)
+	}, PassAuthWithConfig(PassAuthConfig{Check: PassAuthStoreCheck(store, "/login")}))
+	g.PassAuthLoginHandleWithConfig("/login", PassAuthLoginConfig{Login: PassAuthStoreLogin(store, "/private")})
+	g.PassAuthRegisterHandle("/register", config)
+	g.PassAuthLogoutHandle("/logout", config)
+	g.PassAuthPasswordHandle("/password", config)
+	g.PassAuthCertsHandle("/certs", config)
+
+	// Register
+	is.Equal("60 Please create a certificate\r\n", req("/register", nil))
+	is.Equal("10 Enter username\r\n", req("/register", laptop))
+	is.Equal("30 /register/jon%20snow\r\n", req("/register?jon%20snow", laptop))
+	is.Equal("11 Enter password\r\n", req("/register/jon%20snow", laptop))
+	is.Equal("11 Password is too short, enter at least 8 characters\r\n", req("/register/jon%20snow?short", laptop))
+	is.Equal("30 /register/jon%20snow/confirm\r\n", req("/register/jon%20snow?winteriscoming", laptop))
+	is.Equal("11 Confirm password\r\n", req("/register/jon%20snow/confirm", laptop))
+	is.Equal("30 /register\r\n", req("/register/jon%20snow/confirm?winteriscoming", phone))
+	is.Equal("30 /register/jon%20snow\r\n", req("/register/jon%20snow/confirm?typo", laptop))
+	is.Equal("30 /register/jon%20snow/confirm\r\n", req("/register/jon%20snow?winteriscoming", laptop))
+	is.Equal("30 /private\r\n", req("/register/jon%20snow/confirm?winteriscoming", laptop))
+	is.Equal("20 text/gemini\r\nhello jon snow", req("/private", laptop))
+
+	// Taken username
+	is.Equal("30 /register/jon%20snow/confirm\r\n", req("/register/jon%20snow?winteriscoming", phone))
+	is.Equal("20 text/gemini\r\n# Username is taken\n\n=> /register Try another username",
+		req("/register/jon%20snow/confirm?winteriscoming", phone))
+
+	// Login with another certificate
+	is.Equal("30 /login\r\n", req("/private", phone))
+	is.Equal("61 Invalid username or password\r\n", req("/login/jon%20snow?wrong", phone))
+	is.Equal("30 /private\r\n", req("/login/jon snow?winteriscoming", phone))
+	is.Equal("20 text/gemini\r\nhello jon snow", req("/private", phone))
+
+	// List and revoke certificates
+	is.Equal("61 Please login first\r\n", req("/certs", &x509.Certificate{Raw: []byte{3}}))
+
+	certs := req("/certs", laptop)
+	is.True(certs == "20 text/gemini\r\n# Certificates of jon snow\n\n* "+laptopSig+" (this certificate)\n=> /certs/revoke/"+phoneSig+" Revoke "+phoneSig+"\n" ||
+		certs == "20 text/gemini\r\n# Certificates of jon snow\n\n=> /certs/revoke/"+phoneSig+" Revoke "+phoneSig+"\n* "+laptopSig+" (this certificate)\n")
+	is.Equal("30 /certs\r\n", req("/certs/revoke/"+phoneSig, laptop))
+	is.Equal("30 /login\r\n", req("/private", phone))
+
+	// Change password
+	is.Equal("11 Enter current password\r\n", req("/password", laptop))
+	is.Equal("11 Wrong password, enter current password\r\n", req("/password?wrong", laptop))
+	is.Equal("30 /password\r\n", req("/password/new?longenough", laptop))
+	is.Equal("30 /password/new\r\n", req("/password?winteriscoming", laptop))
+	is.Equal("11 Enter new password\r\n", req("/password/new", laptop))
+	is.Equal("30 /password/confirm\r\n", req("/password/new?thenorthremembers", laptop))
+	is.Equal("30 /password/new\r\n", req("/password/confirm?typo", laptop))
+	is.Equal("30 /password/confirm\r\n", req("/password/new?thenorthremembers", laptop))
+	is.Equal("20 text/gemini\r\n# Password changed", req("/password/confirm?thenorthremembers", laptop))
+	is.Equal(ErrInvalidCredentials, store.Authenticate("jon snow", "winteriscoming"))
+	is.NoErr(store.Authenticate("jon snow", "thenorthremembers"))
+
+	// Unknown users are compared against a dummy hash
+	is.Equal(ErrInvalidCredentials, store.Authenticate("arya", "thenorthremembers"))
+	is.True(len(store.dummy) > 0)
+
+	// Logout
+	is.Equal("30 /private\r\n", req("/logout", laptop))
+	is.Equal("30 /login\r\n", req("/private", laptop))
+	is.Equal("61 Please login first\r\n", req("/password", laptop))
+}
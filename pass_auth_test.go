@@ -143,3 +143,54 @@ func TestPassAuth_Errors(t *testing.T) {
 	g.ServeGemini(c)
 	is.Equal(res.Written, "50 oops\r\n")
 }
+
+func TestPassAuth_Migrate(t *testing.T) {
+	var (
+		is      = is.New(t)
+		g       = New()
+		cert    = x509.Certificate{Raw: []byte{1}}
+		other   = x509.Certificate{Raw: []byte{2}}
+		pinned  = map[string]string{CertFingerprint(&cert, FingerprintMD5): "jon"}
+		request = func(path string, cert *x509.Certificate) string {
+			c, res := g.NewFakeContext(path, &tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{cert},
+			})
+			g.ServeGemini(c)
+
+			return res.Written
+		}
+	)
+
+	g.PassAuthLoginHandleWithConfig("/login", PassAuthLoginConfig{
+		Login: func(u, p, sig string, c Context) (string, error) {
+			pinned[sig] = u
+			return "/private", nil
+		},
+	})
+	g.Handle("/private", func(c Context) error {
+		return c.Gemini("private")
+	}, PassAuthWithConfig(PassAuthConfig{
+		Check: func(sig string, c Context) (string, error) {
+			if _, ok := pinned[sig]; ok {
+				return "", nil
+			}
+			return "/login", nil
+		},
+		Migrate: func(legacy, sig string, c Context) error {
+			pinned[sig] = pinned[legacy]
+			delete(pinned, legacy)
+
+			return nil
+		},
+	}))
+
+	is.Equal("20 text/gemini\r\nprivate", request("/private", &cert))
+	is.Equal("jon", pinned[CertFingerprint(&cert, FingerprintSHA256)])
+	is.Equal(1, len(pinned))
+	is.Equal("20 text/gemini\r\nprivate", request("/private", &cert))
+
+	is.Equal("30 /login\r\n", request("/private", &other))
+	is.Equal("30 /private\r\n", request("/login/bob?secret", &other))
+	is.Equal("bob", pinned[CertFingerprint(&other, FingerprintSHA256)])
+	is.Equal("20 text/gemini\r\nprivate", request("/private", &other))
+}
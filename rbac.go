@@ -0,0 +1,164 @@
+package gig
+
+import (
+	"crypto/x509"
+)
+
+type (
+	// RoleResolver defines a function that returns roles of client
+	// certificate. It is only called when certificate is present.
+	RoleResolver func(cert *x509.Certificate, c Context) ([]string, error)
+
+	// RBACConfig defines the config for RBAC middleware.
+	RBACConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Resolver returns roles of client certificate.
+		// Required.
+		Resolver RoleResolver
+	}
+
+	// RequireRoleConfig defines the config for RequireRole middleware.
+	RequireRoleConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Roles lists roles that are allowed, client must have at least one
+		// of them.
+		// Required.
+		Roles []string
+
+		// Message is sent to clients that lack required roles.
+		// Optional. Default value "Certificate Not Authorised".
+		Message string
+	}
+)
+
+const rolesKey = "roles"
+
+var (
+	// DefaultRBACConfig is the default RBAC middleware config.
+	DefaultRBACConfig = RBACConfig{
+		Skipper: DefaultSkipper,
+	}
+
+	// DefaultRequireRoleConfig is the default RequireRole middleware config.
+	DefaultRequireRoleConfig = RequireRoleConfig{
+		Skipper: DefaultSkipper,
+		Message: ErrCertificateNotAuthorised.Message,
+	}
+)
+
+// RBAC returns a middleware that resolves roles of client certificate and
+// stores them in context, see `GetRoles()`. Use `RequireRole()` on routes or
+// groups to restrict access.
+func RBAC(resolver RoleResolver) MiddlewareFunc {
+	c := DefaultRBACConfig
+	c.Resolver = resolver
+
+	return RBACWithConfig(c)
+}
+
+// RBACWithConfig returns a RBAC middleware with config.
+// See `RBAC()`.
+func RBACWithConfig(config RBACConfig) MiddlewareFunc {
+	// Defaults
+	if config.Resolver == nil {
+		panic("gig: rbac middleware requires a resolver")
+	}
+
+	if config.Skipper == nil {
+		config.Skipper = DefaultRBACConfig.Skipper
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			if cert := c.Certificate(); cert != nil {
+				roles, err := config.Resolver(cert, c)
+				if err != nil {
+					return err
+				}
+
+				c.Set(rolesKey, roles)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// RequireRole returns a middleware that lets through clients with any of
+// roles resolved by RBAC middleware. Requests without certificate get
+// ErrClientCertificateRequired, others get ErrCertificateNotAuthorised.
+func RequireRole(roles ...string) MiddlewareFunc {
+	c := DefaultRequireRoleConfig
+	c.Roles = roles
+
+	return RequireRoleWithConfig(c)
+}
+
+// RequireRoleWithConfig returns a RequireRole middleware with config.
+// See `RequireRole()`.
+func RequireRoleWithConfig(config RequireRoleConfig) MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultRequireRoleConfig.Skipper
+	}
+
+	if config.Message == "" {
+		config.Message = DefaultRequireRoleConfig.Message
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			if c.Certificate() == nil {
+				return ErrClientCertificateRequired
+			}
+
+			for _, role := range config.Roles {
+				if HasRole(c, role) {
+					return next(c)
+				}
+			}
+
+			return NewErrorFrom(ErrCertificateNotAuthorised, config.Message)
+		}
+	}
+}
+
+// GetRoles returns roles resolved by RBAC middleware.
+func GetRoles(c Context) []string {
+	roles, _ := c.Get(rolesKey).([]string)
+	return roles
+}
+
+// HasRole reports whether client has role resolved by RBAC middleware.
+func HasRole(c Context, role string) bool {
+	return containsString(GetRoles(c), role)
+}
+
+// RolesByFingerprint returns a RoleResolver that looks up roles by
+// certificate fingerprint computed using alg.
+func RolesByFingerprint(roles map[string][]string, alg FingerprintAlgorithm) RoleResolver {
+	return func(cert *x509.Certificate, c Context) ([]string, error) {
+		return roles[CertFingerprint(cert, alg)], nil
+	}
+}
+
+// RolesBySubject returns a RoleResolver that looks up roles by certificate
+// subject common name. Only use it with certificates verified by
+// `CertAuthCA()`, as anyone can create a certificate with any subject.
+func RolesBySubject(roles map[string][]string) RoleResolver {
+	return func(cert *x509.Certificate, c Context) ([]string, error) {
+		return roles[cert.Subject.CommonName], nil
+	}
+}
@@ -0,0 +1,77 @@
+package gig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestRBAC(t *testing.T) {
+	is := is.New(t)
+
+	var (
+		admin  = &x509.Certificate{Raw: []byte{1}, Subject: pkix.Name{CommonName: "root"}}
+		editor = &x509.Certificate{Raw: []byte{2}, Subject: pkix.Name{CommonName: "ed"}}
+		guest  = &x509.Certificate{Raw: []byte{3}, Subject: pkix.Name{CommonName: "guest"}}
+		broken = &x509.Certificate{Raw: []byte{4}, Subject: pkix.Name{CommonName: "broken"}}
+	)
+
+	byFingerprint := RolesByFingerprint(map[string][]string{
+		CertFingerprint(admin, FingerprintSHA256): {"admin", "editor"},
+	}, FingerprintSHA256)
+	bySubject := RolesBySubject(map[string][]string{
+		"ed": {"editor"},
+	})
+
+	g := New()
+	g.Use(RBAC(func(cert *x509.Certificate, c Context) ([]string, error) {
+		if cert == broken {
+			return nil, errors.New("oops")
+		}
+
+		roles, _ := byFingerprint(cert, c)
+		more, _ := bySubject(cert, c)
+
+		return append(roles, more...), nil
+	}))
+
+	h := func(c Context) error {
+		return c.Text("%s", strings.Join(GetRoles(c), ","))
+	}
+
+	g.Handle("/", h)
+	g.Handle("/edit", h, RequireRole("editor", "admin"))
+
+	plant := g.Group("/admin", RequireRoleWithConfig(RequireRoleConfig{
+		Roles:   []string{"admin"},
+		Message: "Admins only",
+	}))
+	plant.Handle("", h)
+
+	req := func(path string, cert *x509.Certificate) string {
+		var state *tls.ConnectionState
+		if cert != nil {
+			state = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+		}
+
+		c, conn := g.NewFakeContext(path, state)
+		g.ServeGemini(c)
+
+		return conn.Written
+	}
+
+	is.Equal("20 text/plain\r\n", req("/", nil))
+	is.Equal("20 text/plain\r\n", req("/", guest))
+	is.Equal("60 Client Certificate Required\r\n", req("/edit", nil))
+	is.Equal("61 Certificate Not Authorised\r\n", req("/edit", guest))
+	is.Equal("20 text/plain\r\neditor", req("/edit", editor))
+	is.Equal("20 text/plain\r\nadmin,editor", req("/edit", admin))
+	is.Equal("61 Admins only\r\n", req("/admin", editor))
+	is.Equal("20 text/plain\r\nadmin,editor", req("/admin", admin))
+	is.Equal("50 oops\r\n", req("/", broken))
+}
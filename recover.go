@@ -3,6 +3,9 @@ package gig
 import (
 	"fmt"
 	"runtime"
+	"strings"
+	"sync"
+	"time"
 )
 
 type (
@@ -23,6 +26,43 @@ type (
 		// DisablePrintStack disables printing stack trace.
 		// Optional. Default value as false.
 		DisablePrintStack bool
+
+		// Handler is called with recovered panic, e.g. to report it. If it
+		// does not send a response, Message is sent.
+		// Optional.
+		Handler func(c Context, err *PanicError)
+
+		// Message is sent to client with status 50. Panic value is never
+		// sent, as it may contain sensitive details.
+		// Optional. Default value "Permanent Failure".
+		Message string
+
+		// RepeatInterval is how long identical panics, with the same value
+		// raised at the same place, are not printed again. Number of skipped
+		// panics is printed with the next one, or when a different panic is
+		// printed after interval has passed.
+		// Optional. Default value 1 minute. Negative value prints every panic.
+		RepeatInterval time.Duration
+	}
+
+	// PanicError is a panic recovered by Recover middleware.
+	PanicError struct {
+		// Value passed to panic.
+		Value interface{}
+		// Stack trace of goroutine that panicked.
+		Stack []byte
+	}
+
+	// panicLog rate limits printing of identical panics.
+	panicLog struct {
+		mu   sync.Mutex
+		seen map[string]*panicSeen
+	}
+
+	panicSeen struct {
+		value   interface{}
+		printed time.Time
+		skipped int
 	}
 )
 
@@ -33,6 +73,8 @@ var (
 		StackSize:         4 << 10, // 4 KB
 		DisableStackAll:   false,
 		DisablePrintStack: false,
+		Message:           ErrPermanentFailure.Message,
+		RepeatInterval:    time.Minute,
 	}
 )
 
@@ -54,6 +96,16 @@ func RecoverWithConfig(config RecoverConfig) MiddlewareFunc {
 		config.StackSize = DefaultRecoverConfig.StackSize
 	}
 
+	if config.Message == "" {
+		config.Message = DefaultRecoverConfig.Message
+	}
+
+	if config.RepeatInterval == 0 {
+		config.RepeatInterval = DefaultRecoverConfig.RepeatInterval
+	}
+
+	plog := &panicLog{seen: map[string]*panicSeen{}}
+
 	return func(next HandlerFunc) HandlerFunc {
 		return func(c Context) error {
 			if config.Skipper(c) {
@@ -62,18 +114,21 @@ func RecoverWithConfig(config RecoverConfig) MiddlewareFunc {
 
 			defer func() {
 				if r := recover(); r != nil {
-					err, ok := r.(error)
-					if !ok {
-						err = fmt.Errorf("%v", r)
-					}
+					stack := make([]byte, config.StackSize)
+					length := runtime.Stack(stack, !config.DisableStackAll)
+					err := &PanicError{Value: r, Stack: stack[:length]}
 
 					if !config.DisablePrintStack {
-						stack := make([]byte, config.StackSize)
-						length := runtime.Stack(stack, !config.DisableStackAll)
-						fmt.Fprintf(DefaultWriter, "[PANIC RECOVER] %v %s\n", err, stack[:length])
+						plog.print(err, panicLocation(), config.RepeatInterval)
+					}
+
+					if config.Handler != nil {
+						config.Handler(c, err)
 					}
 
-					c.Error(err)
+					if !c.Response().Committed {
+						c.Error(NewErrorFrom(ErrPermanentFailure, config.Message))
+					}
 				}
 			}()
 
@@ -81,3 +136,93 @@ func RecoverWithConfig(config RecoverConfig) MiddlewareFunc {
 		}
 	}
 }
+
+// Error makes it compatible with `error` interface.
+func (pe *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", pe.Value)
+}
+
+// Unwrap returns panic value if it is an error.
+func (pe *PanicError) Unwrap() error {
+	err, _ := pe.Value.(error)
+	return err
+}
+
+// print prints err unless an identical panic was printed within interval.
+// Other panics not printed within interval are forgotten, printing number of
+// their skipped repeats.
+func (l *panicLog) print(err *PanicError, location string, interval time.Duration) {
+	var (
+		key       = fmt.Sprintf("%v\x00%s", err.Value, location)
+		now       = time.Now()
+		skipped   int
+		forgotten []*panicSeen
+	)
+
+	if interval > 0 {
+		l.mu.Lock()
+
+		s := l.seen[key]
+		if s != nil && now.Sub(s.printed) < interval {
+			s.skipped++
+			l.mu.Unlock()
+
+			return
+		}
+
+		for k, v := range l.seen {
+			if k != key && now.Sub(v.printed) >= interval {
+				delete(l.seen, k)
+
+				if v.skipped > 0 {
+					forgotten = append(forgotten, v)
+				}
+			}
+		}
+
+		if s == nil {
+			s = &panicSeen{value: err.Value}
+			l.seen[key] = s
+		}
+
+		skipped = s.skipped
+		s.printed = now
+		s.skipped = 0
+
+		l.mu.Unlock()
+	}
+
+	for _, v := range forgotten {
+		fmt.Fprintf(DefaultWriter, "[PANIC RECOVER] %v (%d identical panics not printed)\n", v.value, v.skipped)
+	}
+
+	if skipped > 0 {
+		fmt.Fprintf(DefaultWriter, "[PANIC RECOVER] %v (%d identical panics not printed) %s\n", err.Value, skipped, err.Stack)
+		return
+	}
+
+	fmt.Fprintf(DefaultWriter, "[PANIC RECOVER] %v %s\n", err.Value, err.Stack)
+}
+
+// panicLocation returns file and line panic was raised at, called from
+// deferred function that recovered it.
+func panicLocation() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+
+	panicking := false
+
+	for {
+		frame, more := frames.Next()
+
+		if strings.HasPrefix(frame.Function, "runtime.") {
+			panicking = true
+		} else if panicking {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+
+		if !more {
+			return ""
+		}
+	}
+}
@@ -2,8 +2,10 @@ package gig
 
 import (
 	"bytes"
+	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/matryer/is"
 )
@@ -26,7 +28,7 @@ func TestRecover(t *testing.T) {
 	is := is.New(t)
 
 	is.NoErr(h(c))
-	is.Equal("50 test\r\n", conn.Written)
+	is.Equal("50 Permanent Failure\r\n", conn.Written)
 	is.True(strings.Contains(buf.String(), "PANIC RECOVER"))
 }
 
@@ -48,6 +50,99 @@ func TestRecover_Defaults(t *testing.T) {
 	is := is.New(t)
 
 	is.NoErr(h(c))
-	is.Equal("50 test\r\n", conn.Written)
+	is.Equal("50 Permanent Failure\r\n", conn.Written)
 	is.True(strings.Contains(buf.String(), "PANIC RECOVER"))
 }
+
+func TestRecover_Handler(t *testing.T) {
+	is := is.New(t)
+	g := New()
+
+	var got *PanicError
+
+	g.Use(RecoverWithConfig(RecoverConfig{
+		DisablePrintStack: true,
+		Handler: func(c Context, err *PanicError) {
+			got = err
+			if c.Path() == "/handled" {
+				_ = c.NoContent(StatusTemporaryFailure, "Try again later")
+			}
+		},
+		Message: "Something went wrong",
+	}))
+
+	g.Handle("/handled", func(c Context) error {
+		panic(errors.New("secret"))
+	})
+	g.Handle("/default", func(c Context) error {
+		panic("secret")
+	})
+
+	is.Equal("40 Try again later\r\n", request("/handled", g))
+	is.Equal("panic: secret", got.Error())
+	is.Equal("secret", errors.Unwrap(got).Error())
+	is.True(bytes.Contains(got.Stack, []byte("recover_test.go")))
+
+	is.Equal("50 Something went wrong\r\n", request("/default", g))
+	is.Equal("secret", got.Value)
+	is.Equal(nil, errors.Unwrap(got))
+}
+
+func TestRecover_Repeat(t *testing.T) {
+	is := is.New(t)
+	buf := new(bytes.Buffer)
+	oldWriter := DefaultWriter
+	DefaultWriter = buf
+
+	defer func() {
+		DefaultWriter = oldWriter
+	}()
+
+	g := New()
+	g.Use(RecoverWithConfig(RecoverConfig{RepeatInterval: 50 * time.Millisecond}))
+	g.Handle("/:v", func(c Context) error {
+		panic(c.Param("v"))
+	})
+
+	for i := 0; i < 3; i++ {
+		request("/a", g)
+	}
+
+	request("/b", g)
+
+	is.Equal(2, strings.Count(buf.String(), "[PANIC RECOVER]"))
+
+	time.Sleep(50 * time.Millisecond)
+	request("/a", g)
+
+	is.Equal(3, strings.Count(buf.String(), "[PANIC RECOVER]"))
+	is.True(strings.Contains(buf.String(), "[PANIC RECOVER] a (2 identical panics not printed)"))
+}
+
+func TestRecover_RepeatForget(t *testing.T) {
+	is := is.New(t)
+	buf := new(bytes.Buffer)
+	oldWriter := DefaultWriter
+	DefaultWriter = buf
+
+	defer func() {
+		DefaultWriter = oldWriter
+	}()
+
+	l := &panicLog{seen: map[string]*panicSeen{}}
+
+	for i := 0; i < 10; i++ {
+		l.print(&PanicError{Value: i}, "here", 50*time.Millisecond)
+	}
+
+	l.print(&PanicError{Value: 0}, "here", 50*time.Millisecond)
+	is.Equal(10, len(l.seen))
+
+	// Panics not repeated within interval are forgotten
+	time.Sleep(50 * time.Millisecond)
+	l.print(&PanicError{Value: "new"}, "here", 50*time.Millisecond)
+
+	is.Equal(1, len(l.seen))
+	is.Equal(12, strings.Count(buf.String(), "[PANIC RECOVER]"))
+	is.True(strings.Contains(buf.String(), "[PANIC RECOVER] 0 (1 identical panics not printed)\n"))
+}
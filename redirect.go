@@ -0,0 +1,294 @@
+package gig
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type (
+	// RedirectConfig defines the config for Redirects middleware.
+	RedirectConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Rules are checked in order, first matching rule wins.
+		// Required.
+		Rules []RedirectRule
+
+		// MaxHops limits how many chained rules are followed before a redirect
+		// is sent, so that clients get one redirect instead of several.
+		// Optional. Default value 10.
+		MaxHops int
+	}
+
+	// RedirectRule maps an old path to a new location.
+	RedirectRule struct {
+		// Kind defines how From is matched against request path.
+		Kind RedirectKind
+
+		// From is an exact path, a path prefix or a regular expression
+		// depending on Kind.
+		From string
+
+		// To is the redirect target. For RedirectPrefix the remainder of the
+		// path is appended to it, for RedirectRegex it may contain submatch
+		// references such as $1.
+		To string
+
+		// Code is the redirect status code.
+		// Optional. Default value StatusRedirectPermanent.
+		Code Status
+
+		re *regexp.Regexp
+	}
+
+	// RedirectKind defines how RedirectRule is matched.
+	RedirectKind uint8
+)
+
+// Redirect rule kinds.
+const (
+	RedirectExact RedirectKind = iota
+	RedirectPrefix
+	RedirectRegex
+)
+
+var (
+	// DefaultRedirectConfig is the default Redirects middleware config.
+	DefaultRedirectConfig = RedirectConfig{
+		Skipper: DefaultSkipper,
+		MaxHops: 10,
+	}
+
+	redirectKinds = map[string]RedirectKind{
+		"exact":  RedirectExact,
+		"prefix": RedirectPrefix,
+		"regex":  RedirectRegex,
+	}
+)
+
+// Redirects returns a middleware that redirects requests according to rules.
+// It should be registered using `Gig#Pre()` so that it runs even for paths
+// that have no route anymore.
+func Redirects(rules ...RedirectRule) MiddlewareFunc {
+	c := DefaultRedirectConfig
+	c.Rules = rules
+
+	return RedirectWithConfig(c)
+}
+
+// RedirectWithConfig returns a Redirects middleware with config.
+// See: `Redirects()`.
+func RedirectWithConfig(config RedirectConfig) MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultRedirectConfig.Skipper
+	}
+
+	if config.MaxHops == 0 {
+		config.MaxHops = DefaultRedirectConfig.MaxHops
+	}
+
+	rules := make([]RedirectRule, len(config.Rules))
+	for i, r := range config.Rules {
+		if err := r.compile(); err != nil {
+			panic(fmt.Sprintf("gig: invalid redirect rule %q: %s", r.From, err))
+		}
+
+		rules[i] = r
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			p := c.URL().Path
+			if p == "" {
+				p = "/"
+			}
+
+			var (
+				code   = StatusRedirectPermanent
+				target string
+				seen   = map[string]bool{p: true}
+			)
+
+			for hop := 0; hop < config.MaxHops; hop++ {
+				rule, to := matchRedirect(rules, p)
+				if rule == nil {
+					break
+				}
+
+				if rule.Code == StatusRedirectTemporary {
+					code = rule.Code
+				}
+
+				target = to
+
+				// Only paths on this capsule can be followed further.
+				if !strings.HasPrefix(to, "/") {
+					break
+				}
+
+				if i := strings.IndexByte(to, '?'); i >= 0 {
+					to = to[:i]
+				}
+
+				if seen[to] {
+					c.Gig().logger().Warn("redirect loop", "path", c.URL().Path)
+					return NewErrorFrom(ErrPermanentFailure, "Redirect loop")
+				}
+
+				// Rule whose target is matched by itself again, such as
+				// prefix /old to /old/new, would be applied until MaxHops.
+				if _, ok := rule.match(to); ok {
+					break
+				}
+
+				seen[to] = true
+				p = to
+			}
+
+			if target == "" {
+				return next(c)
+			}
+
+			if q := c.URL().RawQuery; q != "" && !strings.Contains(target, "?") {
+				target += "?" + q
+			}
+
+			return c.Redirect(code, target)
+		}
+	}
+}
+
+// LoadRedirectRules reads redirect rules from a file. See `ParseRedirectRules`
+// for the format.
+func LoadRedirectRules(file string) ([]RedirectRule, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseRedirectRules(f)
+}
+
+// ParseRedirectRules parses redirect rules, one per line, in the form of
+// "<kind> <from> <to> [code]", where kind is one of exact, prefix or regex.
+// Empty lines and lines starting with # are ignored.
+//
+// Example:
+//
+//	exact  /old-page      /new-page
+//	prefix /blog/         /gemlog/
+//	regex  ^/p/([0-9]+)$  /posts/$1  30
+func ParseRedirectRules(r io.Reader) ([]RedirectRule, error) {
+	var (
+		rules []RedirectRule
+		s     = bufio.NewScanner(r)
+		n     = 0
+	)
+
+	for s.Scan() {
+		n++
+
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 3 && len(fields) != 4 {
+			return nil, fmt.Errorf("line %d: expected 3 or 4 fields, got %d", n, len(fields))
+		}
+
+		kind, ok := redirectKinds[fields[0]]
+		if !ok {
+			return nil, fmt.Errorf("line %d: unknown rule kind %q", n, fields[0])
+		}
+
+		rule := RedirectRule{Kind: kind, From: fields[1], To: fields[2]}
+
+		if len(fields) == 4 {
+			code, err := strconv.Atoi(fields[3])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid code %q", n, fields[3])
+			}
+
+			rule.Code = Status(code)
+		}
+
+		if err := rule.compile(); err != nil {
+			return nil, fmt.Errorf("line %d: %s", n, err)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (r *RedirectRule) compile() (err error) {
+	if r.Code == 0 {
+		r.Code = StatusRedirectPermanent
+	}
+
+	if r.Code != StatusRedirectTemporary && r.Code != StatusRedirectPermanent {
+		return ErrInvalidRedirectCode
+	}
+
+	if r.Kind == RedirectRegex && r.re == nil {
+		r.re, err = regexp.Compile(r.From)
+	}
+
+	return
+}
+
+// matchRedirect returns first rule matching p and its target, or nil.
+func matchRedirect(rules []RedirectRule, p string) (*RedirectRule, string) {
+	for i := range rules {
+		if to, ok := rules[i].match(p); ok {
+			return &rules[i], to
+		}
+	}
+
+	return nil, ""
+}
+
+// match returns target for p if rule matches it. Prefix rules match whole
+// path segments only, so /blog matches /blog and /blog/post, not /blogger.
+func (r *RedirectRule) match(p string) (string, bool) {
+	switch r.Kind {
+	case RedirectExact:
+		if p == r.From {
+			return r.To, true
+		}
+	case RedirectPrefix:
+		if !strings.HasPrefix(p, r.From) {
+			return "", false
+		}
+
+		rest := p[len(r.From):]
+		if rest == "" || rest[0] == '/' || strings.HasSuffix(r.From, "/") {
+			return r.To + rest, true
+		}
+	case RedirectRegex:
+		if r.re.MatchString(p) {
+			return r.re.ReplaceAllString(p, r.To), true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,90 @@
+package gig
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestRedirects(t *testing.T) {
+	is := is.New(t)
+
+	g := New()
+	g.Pre(Redirects(
+		RedirectRule{Kind: RedirectExact, From: "/old", To: "/new"},
+		RedirectRule{Kind: RedirectExact, From: "/tmp", To: "/old", Code: StatusRedirectTemporary},
+		RedirectRule{Kind: RedirectPrefix, From: "/blog/", To: "/gemlog/"},
+		RedirectRule{Kind: RedirectRegex, From: `^/p/([0-9]+)$`, To: "/posts/$1"},
+		RedirectRule{Kind: RedirectExact, From: "/away", To: "gemini://example.org/"},
+		RedirectRule{Kind: RedirectExact, From: "/loop1", To: "/loop2"},
+		RedirectRule{Kind: RedirectExact, From: "/loop2", To: "/loop1"},
+		RedirectRule{Kind: RedirectPrefix, From: "/docs", To: "/docs/v2"},
+		RedirectRule{Kind: RedirectPrefix, From: "/arch", To: "/archive"},
+	))
+	g.Handle("/new", func(c Context) error {
+		return c.Gemini("new")
+	})
+
+	is.Equal("31 /new\r\n", request("/old", g))
+	is.Equal("30 /new\r\n", request("/tmp", g))
+	is.Equal("31 /gemlog/2020/hello.gmi\r\n", request("/blog/2020/hello.gmi", g))
+	is.Equal("31 /posts/12\r\n", request("/p/12", g))
+	is.Equal("51 Not Found\r\n", request("/p/abc", g))
+	is.Equal("31 gemini://example.org/\r\n", request("/away", g))
+	is.Equal("31 /new?q\r\n", request("/old?q", g))
+	is.Equal("50 Redirect loop\r\n", request("/loop1", g))
+	is.Equal("20 text/gemini\r\nnew", request("/new", g))
+
+	// Rule matching its own target is applied once
+	is.Equal("31 /docs/v2/intro\r\n", request("/docs/intro", g))
+
+	// Prefix matches whole segments
+	is.Equal("31 /archive\r\n", request("/arch", g))
+	is.Equal("31 /archive/2020\r\n", request("/arch/2020", g))
+	is.Equal("51 Not Found\r\n", request("/archaic", g))
+}
+
+func TestRedirectWithConfig_Invalid(t *testing.T) {
+	is := is.New(t)
+
+	defer func() {
+		is.True(recover() != nil)
+	}()
+
+	RedirectWithConfig(RedirectConfig{Rules: []RedirectRule{
+		{Kind: RedirectExact, From: "/a", To: "/b", Code: StatusSuccess},
+	}})
+}
+
+func TestParseRedirectRules(t *testing.T) {
+	is := is.New(t)
+
+	rules, err := ParseRedirectRules(strings.NewReader(`
+# old capsule layout
+exact  /old-page      /new-page
+prefix /blog/         /gemlog/
+regex  ^/p/([0-9]+)$  /posts/$1  30
+`))
+	is.NoErr(err)
+	is.Equal(3, len(rules))
+	is.Equal(RedirectExact, rules[0].Kind)
+	is.Equal(StatusRedirectPermanent, rules[0].Code)
+	is.Equal(RedirectPrefix, rules[1].Kind)
+	is.Equal(RedirectRegex, rules[2].Kind)
+	is.Equal(StatusRedirectTemporary, rules[2].Code)
+
+	for _, in := range []string{
+		"exact /a",
+		"glob /a /b",
+		"exact /a /b 2x",
+		"exact /a /b 20",
+		"regex ( /b",
+	} {
+		_, err = ParseRedirectRules(strings.NewReader(in))
+		is.True(err != nil)
+	}
+
+	_, err = LoadRedirectRules("_fixture/missing")
+	is.True(err != nil)
+}
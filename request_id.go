@@ -0,0 +1,64 @@
+package gig
+
+type (
+	// RequestIDConfig defines the config for RequestID middleware.
+	RequestIDConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Generator returns a new request ID.
+		// Optional. Default value random 16 hex characters.
+		Generator func() string
+	}
+)
+
+const requestIDKey = "request_id"
+
+var (
+	// DefaultRequestIDConfig is the default RequestID middleware config.
+	DefaultRequestIDConfig = RequestIDConfig{
+		Skipper:   DefaultSkipper,
+		Generator: generateRequestID,
+	}
+)
+
+// RequestID returns a middleware that generates an ID for every request. It
+// is returned by `GetRequestID()` and logged by Logger as `${id}` tag.
+func RequestID() MiddlewareFunc {
+	return RequestIDWithConfig(DefaultRequestIDConfig)
+}
+
+// RequestIDWithConfig returns a RequestID middleware with config.
+// See: `RequestID()`.
+func RequestIDWithConfig(config RequestIDConfig) MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultRequestIDConfig.Skipper
+	}
+
+	if config.Generator == nil {
+		config.Generator = DefaultRequestIDConfig.Generator
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			c.Set(requestIDKey, config.Generator())
+
+			return next(c)
+		}
+	}
+}
+
+// GetRequestID returns ID set by RequestID middleware, or empty string.
+func GetRequestID(c Context) string {
+	id, _ := c.Get(requestIDKey).(string)
+	return id
+}
+
+func generateRequestID() string {
+	return randomID()[:16]
+}
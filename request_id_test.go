@@ -0,0 +1,35 @@
+package gig
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestRequestID(t *testing.T) {
+	is := is.New(t)
+	buf := new(bytes.Buffer)
+
+	g := New()
+	g.Use(LoggerWithConfig(LoggerConfig{Format: "${id} ${status}\n", Output: buf}))
+	g.Use(RequestIDWithConfig(RequestIDConfig{
+		Generator: func() string { return "abc" },
+	}))
+	g.Handle("/", func(c Context) error {
+		return c.Text("%s", GetRequestID(c))
+	})
+
+	is.Equal("20 text/plain\r\nabc", request("/", g))
+	is.Equal("abc 20\n", buf.String())
+
+	g = New()
+	g.Use(RequestID())
+	g.Handle("/", func(c Context) error {
+		return c.Text("%s", GetRequestID(c))
+	})
+
+	a, b := request("/", g), request("/", g)
+	is.Equal(len("20 text/plain\r\n")+16, len(a))
+	is.True(a != b)
+}
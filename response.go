@@ -14,6 +14,7 @@ type (
 		Size      int64
 		Committed bool
 		err       error
+		onHeader  func(Status, string) // Called after header is written
 	}
 )
 
@@ -40,6 +41,10 @@ func (r *Response) WriteHeader(code Status, meta string) error {
 	n, r.err = r.Writer.Write([]byte(fmt.Sprintf("%d %s\r\n", code, meta)))
 	r.Committed = true
 
+	if r.onHeader != nil {
+		r.onHeader(code, meta)
+	}
+
 	if r.err != nil {
 		return r.err
 	}
@@ -86,4 +91,5 @@ func (r *Response) reset(w io.Writer) {
 	r.Status = StatusSuccess
 	r.Committed = false
 	r.err = nil
+	r.onHeader = nil
 }
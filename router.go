@@ -1,14 +1,23 @@
 package gig
 
 import (
+	"fmt"
+	"net/url"
+	"regexp"
 	"strings"
 )
 
 type (
 	router struct {
 		tree   *node
-		routes map[string]*Route
+		routes []*Route
+		paths  map[string]int // Index of route in routes by path
+		names  map[string]*Route
+		groups []*Group
+		host   string // Host pattern, empty for default router
 		gig    *Gig
+
+		conflicts []string // Descriptions of routes registered over others
 	}
 	node struct {
 		kind     kind
@@ -19,6 +28,15 @@ type (
 		ppath    string
 		pnames   []string
 		handler  HandlerFunc
+		variants []*variant
+	}
+	// variant is a handler of a route with param constraints. Routes of the
+	// same shape share a node and are tried in registration order.
+	variant struct {
+		handler     HandlerFunc
+		ppath       string
+		pnames      []string
+		constraints []*constraint
 	}
 	kind     uint8
 	children []*node
@@ -32,9 +50,10 @@ const (
 
 func newRouter(g *Gig) *router {
 	return &router{
-		tree:   &node{},
-		routes: map[string]*Route{},
-		gig:    g,
+		tree:  &node{},
+		paths: map[string]int{},
+		names: map[string]*Route{},
+		gig:   g,
 	}
 }
 
@@ -51,35 +70,115 @@ func (r *router) add(path string, h HandlerFunc) {
 	pnames := []string{} // Param names
 	ppath := path        // Pristine path
 
+	var constraints []*constraint // Param constraints, aligned with pnames
+
 	for i, l := 0, len(path); i < l; i++ {
 		if path[i] == ':' {
 			j := i + 1
 
 			r.insert(path[:i], nil, skind, "", nil)
 
-			for ; i < l && path[i] != '/'; i++ {
-			}
+			i = paramEnd(path, i)
+			name, expr := splitParam(path[j:i])
 
-			pnames = append(pnames, path[j:i])
+			pnames = append(pnames, name)
+			constraints = append(constraints, newConstraint(expr))
 			path = path[:j] + path[i:]
 			i, l = j, len(path)
 
 			if i == l {
-				r.insert(path[:i], h, pkind, ppath, pnames)
+				r.insert(path[:i], nil, pkind, "", pnames)
 			} else {
 				r.insert(path[:i], nil, pkind, "", nil)
 			}
 		} else if path[i] == '*' {
 			r.insert(path[:i], nil, skind, "", nil)
 			pnames = append(pnames, "*")
-			r.insert(path[:i+1], h, akind, ppath, pnames)
+			constraints = append(constraints, nil)
+			r.insert(path[:i+1], nil, akind, "", pnames)
+		}
+	}
+
+	n := r.insert(path, nil, skind, "", pnames)
+
+	for _, c := range constraints {
+		if c != nil {
+			v := &variant{handler: h, ppath: ppath, pnames: pnames, constraints: constraints}
+
+			for _, old := range n.variants {
+				if old.ppath == ppath || old.sameConstraints(v) {
+					r.conflict(ppath, old.ppath)
+				}
+			}
+
+			n.addVariant(v)
+
+			return
+		}
+	}
+
+	if n.ppath != "" {
+		r.conflict(ppath, n.ppath)
+	}
+
+	n.handler = h
+	n.ppath = ppath
+	n.pnames = pnames
+}
+
+// conflict records that route at path shadows route at existing path, or
+// panics if Gig is configured to do so.
+func (r *router) conflict(path, existing string) {
+	desc := fmt.Sprintf("%s%s conflicts with %s%s", r.host, path, r.host, existing)
+	if path == existing {
+		desc = fmt.Sprintf("%s%s is registered twice", r.host, path)
+	}
+
+	if r.gig.PanicOnConflict {
+		panic(fmt.Sprintf("gig: %s: %s", ErrRouteConflict, desc))
+	}
+
+	r.gig.logger().Warn(ErrRouteConflict.Error()+", using last", "conflict", desc)
+
+	r.conflicts = append(r.conflicts, desc)
+}
+
+// addRoute records route in registration order, replacing a previous route
+// with the same path.
+func (r *router) addRoute(route *Route) {
+	if i, ok := r.paths[route.Path]; ok {
+		old := r.routes[i]
+		if r.names[old.Name] == old {
+			delete(r.names, old.Name)
+		}
+
+		r.routes[i] = route
+
+		return
+	}
+
+	r.paths[route.Path] = len(r.routes)
+	r.routes = append(r.routes, route)
+}
+
+// route returns route with explicit name, or the first route registered with
+// a handler of that name.
+func (r *router) route(name string) *Route {
+	if route, ok := r.names[name]; ok {
+		return route
+	}
+
+	for _, route := range r.routes {
+		if route.Name == name {
+			return route
 		}
 	}
 
-	r.insert(path, h, skind, ppath, pnames)
+	return nil
 }
 
-func (r *router) insert(path string, h HandlerFunc, t kind, ppath string, pnames []string) {
+// insert adds nodes for path to the tree and returns the node matching it.
+func (r *router) insert(path string, h HandlerFunc, t kind, ppath string, pnames []string) *node {
 	// Adjust max param
 	l := len(pnames)
 	if *r.gig.maxParam < l {
@@ -122,6 +221,7 @@ func (r *router) insert(path string, h HandlerFunc, t kind, ppath string, pnames
 		case l < pl:
 			// Split node
 			n := newNode(cn.kind, cn.prefix[l:], cn, cn.children, cn.handler, cn.ppath, cn.pnames)
+			n.variants = cn.variants
 
 			// Update parent path for all children to new node
 			for _, child := range cn.children {
@@ -133,8 +233,10 @@ func (r *router) insert(path string, h HandlerFunc, t kind, ppath string, pnames
 			cn.label = cn.prefix[0]
 			cn.prefix = cn.prefix[:l]
 			cn.children = nil
+			cn.handler = nil
 			cn.ppath = ""
 			cn.pnames = nil
+			cn.variants = nil
 
 			cn.addChild(n)
 
@@ -149,6 +251,8 @@ func (r *router) insert(path string, h HandlerFunc, t kind, ppath string, pnames
 				n = newNode(t, search[l:], cn, nil, nil, ppath, pnames)
 				n.handler = h
 				cn.addChild(n)
+
+				return n
 			}
 		case l < sl:
 			search = search[l:]
@@ -163,6 +267,8 @@ func (r *router) insert(path string, h HandlerFunc, t kind, ppath string, pnames
 			n := newNode(t, search, cn, nil, nil, ppath, pnames)
 			n.handler = h
 			cn.addChild(n)
+
+			return n
 		case h != nil:
 			// Node already exists
 			cn.handler = h
@@ -173,7 +279,7 @@ func (r *router) insert(path string, h HandlerFunc, t kind, ppath string, pnames
 			}
 		}
 
-		return
+		return cn
 	}
 }
 
@@ -190,6 +296,64 @@ func newNode(t kind, pre string, p *node, c children, h HandlerFunc, ppath strin
 	}
 }
 
+func (n *node) addVariant(v *variant) {
+	if len(n.pnames) == 0 {
+		n.pnames = v.pnames
+	}
+
+	for i, old := range n.variants {
+		if old.ppath == v.ppath {
+			n.variants[i] = v
+			return
+		}
+	}
+
+	n.variants = append(n.variants, v)
+}
+
+// isLeaf reports whether a route ends at node.
+func (n *node) isLeaf() bool {
+	return n.ppath != "" || len(n.variants) > 0
+}
+
+// endpoint returns handler, path and param names of the first variant whose
+// constraints are satisfied by pvalues, falling back to the node's handler.
+func (n *node) endpoint(pvalues []string) (HandlerFunc, string, []string) {
+	for _, v := range n.variants {
+		if v.match(pvalues) {
+			return v.handler, v.ppath, v.pnames
+		}
+	}
+
+	return n.handler, n.ppath, n.pnames
+}
+
+// sameConstraints reports whether v and o match exactly the same param values.
+func (v *variant) sameConstraints(o *variant) bool {
+	if len(v.constraints) != len(o.constraints) {
+		return false
+	}
+
+	for i, c := range v.constraints {
+		oc := o.constraints[i]
+		if (c == nil) != (oc == nil) || (c != nil && c.re.String() != oc.re.String()) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (v *variant) match(pvalues []string) bool {
+	for i, c := range v.constraints {
+		if c != nil && (i >= len(pvalues) || !c.match(pvalues[i])) {
+			return false
+		}
+	}
+
+	return true
+}
+
 func (n *node) addChild(c *node) {
 	n.children = append(n.children, c)
 }
@@ -225,9 +389,39 @@ func (n *node) findChildByKind(t kind) *node {
 }
 
 // find lookup a handler registered for path. It also parses URL for path
-// parameters and load them into context.
+// parameters and load them into context. Unmatched paths get not found handler
+// of the most specific group.
 func (r *router) find(path string, c Context) {
 	ctx := c.(*context)
+	ctx.handler = nil
+
+	r.lookup(path, ctx)
+
+	ctx.matched = ctx.handler != nil
+
+	if ctx.handler == nil {
+		ctx.handler = r.notFound(path)
+	}
+}
+
+// notFound returns not found handler of the most specific group matching path.
+func (r *router) notFound(path string) HandlerFunc {
+	var group *Group
+
+	for _, g := range r.groups {
+		if g.match(path) && (group == nil || len(g.prefix) > len(group.prefix)) {
+			group = g
+		}
+	}
+
+	if group == nil {
+		return NotFoundHandler
+	}
+
+	return group.notFoundHandler()
+}
+
+func (r *router) lookup(path string, ctx *context) {
 	ctx.path = path
 	cn := r.tree // Current node as root
 
@@ -268,7 +462,7 @@ func (r *router) find(path string, c Context) {
 			// Continue search
 			search = search[l:]
 			// Finish routing if no remaining search and we are on an leaf node
-			if search == "" && (nn == nil || cn.parent == nil || cn.ppath != "") {
+			if search == "" && (nn == nil || cn.parent == nil || cn.isLeaf()) {
 				break
 			}
 		}
@@ -390,27 +584,182 @@ func (r *router) find(path string, c Context) {
 		return // Not found
 	}
 
-	ctx.handler = cn.handler
-	ctx.path = cn.ppath
-	ctx.pnames = cn.pnames
+	ctx.handler, ctx.path, ctx.pnames = cn.endpoint(pvalues)
 
 	// NOTE: Slow zone...
-	if ctx.handler == nil {
-		ctx.handler = NotFoundHandler
-
+	if ctx.handler == nil && len(cn.variants) == 0 {
 		// Dig further for any
 		if cn = cn.findChildByKind(akind); cn == nil {
 			return
 		}
 
-		if cn.handler != nil {
-			ctx.handler = cn.handler
-		} else {
-			ctx.handler = NotFoundHandler
+		pvalues[len(cn.pnames)-1] = ""
+		ctx.handler, ctx.path, ctx.pnames = cn.endpoint(pvalues)
+	}
+
+	if ctx.handler == nil && cn.variants != nil {
+		// Param constraints not satisfied, try other routes as if the param
+		// did not match
+		ctx.handler, ctx.path, ctx.pnames = r.tree.resolve(path, pvalues, 0)
+
+		if ctx.handler == nil {
+			ctx.path = path
 		}
+	}
+}
 
-		ctx.path = cn.ppath
-		ctx.pnames = cn.pnames
-		pvalues[len(cn.pnames)-1] = ""
+// resolve matches search against n and its descendants, backtracking in order
+// static > param > any until a route with satisfied constraints is found.
+// It is slower than lookup, so only used when lookup fails on constraints.
+func (n *node) resolve(search string, pvalues []string, np int) (HandlerFunc, string, []string) {
+	switch n.kind {
+	case skind:
+		if !strings.HasPrefix(search, n.prefix) {
+			return nil, "", nil
+		}
+
+		search = search[len(n.prefix):]
+	case pkind:
+		if np == len(pvalues) {
+			return nil, "", nil
+		}
+
+		i := strings.IndexByte(search, '/')
+		if i < 0 {
+			i = len(search)
+		}
+
+		pvalues[np] = search[:i]
+		np++
+		search = search[i:]
+	case akind:
+		pvalues[len(n.pnames)-1] = search
+		return n.endpoint(pvalues)
+	}
+
+	if search == "" {
+		if h, ppath, pnames := n.endpoint(pvalues); h != nil {
+			return h, ppath, pnames
+		}
+
+		if c := n.findChildByKind(akind); c != nil {
+			return c.resolve(search, pvalues, np)
+		}
+
+		return nil, "", nil
 	}
+
+	for _, t := range []kind{skind, pkind, akind} {
+		for _, c := range n.children {
+			if c.kind != t || (t == skind && c.label != search[0]) {
+				continue
+			}
+
+			if h, ppath, pnames := c.resolve(search, pvalues, np); h != nil {
+				return h, ppath, pnames
+			}
+		}
+	}
+
+	return nil, "", nil
+}
+
+// matchHost reports whether host matches pattern label by label, returning
+// names and values of named labels.
+func matchHost(pattern, host string) (names, values []string, ok bool) {
+	var (
+		pl = strings.Split(pattern, ".")
+		hl = strings.Split(host, ".")
+	)
+
+	if len(pl) != len(hl) {
+		return nil, nil, false
+	}
+
+	for i, l := range pl {
+		switch {
+		case l == "*":
+		case strings.HasPrefix(l, ":"):
+			names = append(names, l[1:])
+			values = append(values, hl[i])
+		case l != hl[i]:
+			return nil, nil, false
+		}
+	}
+
+	return names, values, true
+}
+
+// paramEnd returns the index right after the param starting at path[i],
+// skipping over its constraint which may contain slashes.
+func paramEnd(path string, i int) int {
+	depth := 0
+
+	for l := len(path); i < l; i++ {
+		switch path[i] {
+		case '<':
+			depth++
+		case '>':
+			depth--
+		case '/':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return i
+}
+
+// splitParam splits param token such as "id<int>" into name and constraint.
+func splitParam(token string) (name, expr string) {
+	i := strings.IndexByte(token, '<')
+	if i < 0 || token[len(token)-1] != '>' {
+		return token, ""
+	}
+
+	return token[:i], token[i+1 : len(token)-1]
+}
+
+type constraint struct {
+	re *regexp.Regexp
+}
+
+// Named param constraints.
+var constraints = map[string]string{
+	"int":   `-?[0-9]+`,
+	"uint":  `[0-9]+`,
+	"alpha": `[a-zA-Z]+`,
+	"alnum": `[a-zA-Z0-9]+`,
+	"hex":   `[0-9a-fA-F]+`,
+	"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+}
+
+// newConstraint returns nil for empty expr. Otherwise expr is either a name
+// of a known constraint or a regular expression which must match whole value.
+func newConstraint(expr string) *constraint {
+	if expr == "" {
+		return nil
+	}
+
+	if named, ok := constraints[expr]; ok {
+		expr = named
+	}
+
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		panic(fmt.Sprintf("gig: invalid param constraint %q: %s", expr, err))
+	}
+
+	return &constraint{re: re}
+}
+
+func (c *constraint) match(v string) bool {
+	if strings.IndexByte(v, '%') >= 0 {
+		if u, err := url.PathUnescape(v); err == nil {
+			v = u
+		}
+	}
+
+	return c.re.MatchString(v)
 }
@@ -1,6 +1,7 @@
 package gig
 
 import (
+	"errors"
 	"strings"
 	"testing"
 
@@ -9,360 +10,360 @@ import (
 
 var (
 	staticRoutes = []*Route{
-		{"/", ""},
-		{"/cmd.html", ""},
-		{"/code.html", ""},
-		{"/contrib.html", ""},
-		{"/contribute.html", ""},
-		{"/debugging_with_gdb.html", ""},
-		{"/docs.html", ""},
-		{"/effective_go.html", ""},
-		{"/files.log", ""},
-		{"/gccgo_contribute.html", ""},
-		{"/gccgo_install.html", ""},
-		{"/go-logo-black.png", ""},
-		{"/go-logo-blue.png", ""},
-		{"/go-logo-white.png", ""},
-		{"/go1.1.html", ""},
-		{"/go1.2.html", ""},
-		{"/go1.html", ""},
-		{"/go1compat.html", ""},
-		{"/go_faq.html", ""},
-		{"/go_mem.html", ""},
-		{"/go_spec.html", ""},
-		{"/help.html", ""},
-		{"/ie.css", ""},
-		{"/install-source.html", ""},
-		{"/install.html", ""},
-		{"/logo-153x55.png", ""},
-		{"/Makefile", ""},
-		{"/root.html", ""},
-		{"/share.png", ""},
-		{"/sieve.gif", ""},
-		{"/tos.html", ""},
-		{"/articles/", ""},
-		{"/articles/go_command.html", ""},
-		{"/articles/index.html", ""},
-		{"/articles/wiki/", ""},
-		{"/articles/wiki/edit.html", ""},
-		{"/articles/wiki/final-noclosure.go", ""},
-		{"/articles/wiki/final-noerror.go", ""},
-		{"/articles/wiki/final-parsetemplate.go", ""},
-		{"/articles/wiki/final-template.go", ""},
-		{"/articles/wiki/final.go", ""},
-		{"/articles/wiki/get.go", ""},
-		{"/articles/wiki/http-sample.go", ""},
-		{"/articles/wiki/index.html", ""},
-		{"/articles/wiki/Makefile", ""},
-		{"/articles/wiki/notemplate.go", ""},
-		{"/articles/wiki/part1-noerror.go", ""},
-		{"/articles/wiki/part1.go", ""},
-		{"/articles/wiki/part2.go", ""},
-		{"/articles/wiki/part3-errorhandling.go", ""},
-		{"/articles/wiki/part3.go", ""},
-		{"/articles/wiki/test.bash", ""},
-		{"/articles/wiki/test_edit.good", ""},
-		{"/articles/wiki/test_Test.txt.good", ""},
-		{"/articles/wiki/test_view.good", ""},
-		{"/articles/wiki/view.html", ""},
-		{"/codewalk/", ""},
-		{"/codewalk/codewalk.css", ""},
-		{"/codewalk/codewalk.js", ""},
-		{"/codewalk/codewalk.xml", ""},
-		{"/codewalk/functions.xml", ""},
-		{"/codewalk/markov.go", ""},
-		{"/codewalk/markov.xml", ""},
-		{"/codewalk/pig.go", ""},
-		{"/codewalk/popout.png", ""},
-		{"/codewalk/run", ""},
-		{"/codewalk/sharemem.xml", ""},
-		{"/codewalk/urlpoll.go", ""},
-		{"/devel/", ""},
-		{"/devel/release.html", ""},
-		{"/devel/weekly.html", ""},
-		{"/gopher/", ""},
-		{"/gopher/appenginegopher.jpg", ""},
-		{"/gopher/appenginegophercolor.jpg", ""},
-		{"/gopher/appenginelogo.gif", ""},
-		{"/gopher/bumper.png", ""},
-		{"/gopher/bumper192x108.png", ""},
-		{"/gopher/bumper320x180.png", ""},
-		{"/gopher/bumper480x270.png", ""},
-		{"/gopher/bumper640x360.png", ""},
-		{"/gopher/doc.png", ""},
-		{"/gopher/frontpage.png", ""},
-		{"/gopher/gopherbw.png", ""},
-		{"/gopher/gophercolor.png", ""},
-		{"/gopher/gophercolor16x16.png", ""},
-		{"/gopher/help.png", ""},
-		{"/gopher/pkg.png", ""},
-		{"/gopher/project.png", ""},
-		{"/gopher/ref.png", ""},
-		{"/gopher/run.png", ""},
-		{"/gopher/talks.png", ""},
-		{"/gopher/pencil/", ""},
-		{"/gopher/pencil/gopherhat.jpg", ""},
-		{"/gopher/pencil/gopherhelmet.jpg", ""},
-		{"/gopher/pencil/gophermega.jpg", ""},
-		{"/gopher/pencil/gopherrunning.jpg", ""},
-		{"/gopher/pencil/gopherswim.jpg", ""},
-		{"/gopher/pencil/gopherswrench.jpg", ""},
-		{"/play/", ""},
-		{"/play/fib.go", ""},
-		{"/play/hello.go", ""},
-		{"/play/life.go", ""},
-		{"/play/peano.go", ""},
-		{"/play/pi.go", ""},
-		{"/play/sieve.go", ""},
-		{"/play/solitaire.go", ""},
-		{"/play/tree.go", ""},
-		{"/progs/", ""},
-		{"/progs/cgo1.go", ""},
-		{"/progs/cgo2.go", ""},
-		{"/progs/cgo3.go", ""},
-		{"/progs/cgo4.go", ""},
-		{"/progs/defer.go", ""},
-		{"/progs/defer.out", ""},
-		{"/progs/defer2.go", ""},
-		{"/progs/defer2.out", ""},
-		{"/progs/eff_bytesize.go", ""},
-		{"/progs/eff_bytesize.out", ""},
-		{"/progs/eff_qr.go", ""},
-		{"/progs/eff_sequence.go", ""},
-		{"/progs/eff_sequence.out", ""},
-		{"/progs/eff_unused1.go", ""},
-		{"/progs/eff_unused2.go", ""},
-		{"/progs/error.go", ""},
-		{"/progs/error2.go", ""},
-		{"/progs/error3.go", ""},
-		{"/progs/error4.go", ""},
-		{"/progs/go1.go", ""},
-		{"/progs/gobs1.go", ""},
-		{"/progs/gobs2.go", ""},
-		{"/progs/image_draw.go", ""},
-		{"/progs/image_package1.go", ""},
-		{"/progs/image_package1.out", ""},
-		{"/progs/image_package2.go", ""},
-		{"/progs/image_package2.out", ""},
-		{"/progs/image_package3.go", ""},
-		{"/progs/image_package3.out", ""},
-		{"/progs/image_package4.go", ""},
-		{"/progs/image_package4.out", ""},
-		{"/progs/image_package5.go", ""},
-		{"/progs/image_package5.out", ""},
-		{"/progs/image_package6.go", ""},
-		{"/progs/image_package6.out", ""},
-		{"/progs/interface.go", ""},
-		{"/progs/interface2.go", ""},
-		{"/progs/interface2.out", ""},
-		{"/progs/json1.go", ""},
-		{"/progs/json2.go", ""},
-		{"/progs/json2.out", ""},
-		{"/progs/json3.go", ""},
-		{"/progs/json4.go", ""},
-		{"/progs/json5.go", ""},
-		{"/progs/run", ""},
-		{"/progs/slices.go", ""},
-		{"/progs/timeout1.go", ""},
-		{"/progs/timeout2.go", ""},
-		{"/progs/update.bash", ""},
+		{Path: "/"},
+		{Path: "/cmd.html"},
+		{Path: "/code.html"},
+		{Path: "/contrib.html"},
+		{Path: "/contribute.html"},
+		{Path: "/debugging_with_gdb.html"},
+		{Path: "/docs.html"},
+		{Path: "/effective_go.html"},
+		{Path: "/files.log"},
+		{Path: "/gccgo_contribute.html"},
+		{Path: "/gccgo_install.html"},
+		{Path: "/go-logo-black.png"},
+		{Path: "/go-logo-blue.png"},
+		{Path: "/go-logo-white.png"},
+		{Path: "/go1.1.html"},
+		{Path: "/go1.2.html"},
+		{Path: "/go1.html"},
+		{Path: "/go1compat.html"},
+		{Path: "/go_faq.html"},
+		{Path: "/go_mem.html"},
+		{Path: "/go_spec.html"},
+		{Path: "/help.html"},
+		{Path: "/ie.css"},
+		{Path: "/install-source.html"},
+		{Path: "/install.html"},
+		{Path: "/logo-153x55.png"},
+		{Path: "/Makefile"},
+		{Path: "/root.html"},
+		{Path: "/share.png"},
+		{Path: "/sieve.gif"},
+		{Path: "/tos.html"},
+		{Path: "/articles/"},
+		{Path: "/articles/go_command.html"},
+		{Path: "/articles/index.html"},
+		{Path: "/articles/wiki/"},
+		{Path: "/articles/wiki/edit.html"},
+		{Path: "/articles/wiki/final-noclosure.go"},
+		{Path: "/articles/wiki/final-noerror.go"},
+		{Path: "/articles/wiki/final-parsetemplate.go"},
+		{Path: "/articles/wiki/final-template.go"},
+		{Path: "/articles/wiki/final.go"},
+		{Path: "/articles/wiki/get.go"},
+		{Path: "/articles/wiki/http-sample.go"},
+		{Path: "/articles/wiki/index.html"},
+		{Path: "/articles/wiki/Makefile"},
+		{Path: "/articles/wiki/notemplate.go"},
+		{Path: "/articles/wiki/part1-noerror.go"},
+		{Path: "/articles/wiki/part1.go"},
+		{Path: "/articles/wiki/part2.go"},
+		{Path: "/articles/wiki/part3-errorhandling.go"},
+		{Path: "/articles/wiki/part3.go"},
+		{Path: "/articles/wiki/test.bash"},
+		{Path: "/articles/wiki/test_edit.good"},
+		{Path: "/articles/wiki/test_Test.txt.good"},
+		{Path: "/articles/wiki/test_view.good"},
+		{Path: "/articles/wiki/view.html"},
+		{Path: "/codewalk/"},
+		{Path: "/codewalk/codewalk.css"},
+		{Path: "/codewalk/codewalk.js"},
+		{Path: "/codewalk/codewalk.xml"},
+		{Path: "/codewalk/functions.xml"},
+		{Path: "/codewalk/markov.go"},
+		{Path: "/codewalk/markov.xml"},
+		{Path: "/codewalk/pig.go"},
+		{Path: "/codewalk/popout.png"},
+		{Path: "/codewalk/run"},
+		{Path: "/codewalk/sharemem.xml"},
+		{Path: "/codewalk/urlpoll.go"},
+		{Path: "/devel/"},
+		{Path: "/devel/release.html"},
+		{Path: "/devel/weekly.html"},
+		{Path: "/gopher/"},
+		{Path: "/gopher/appenginegopher.jpg"},
+		{Path: "/gopher/appenginegophercolor.jpg"},
+		{Path: "/gopher/appenginelogo.gif"},
+		{Path: "/gopher/bumper.png"},
+		{Path: "/gopher/bumper192x108.png"},
+		{Path: "/gopher/bumper320x180.png"},
+		{Path: "/gopher/bumper480x270.png"},
+		{Path: "/gopher/bumper640x360.png"},
+		{Path: "/gopher/doc.png"},
+		{Path: "/gopher/frontpage.png"},
+		{Path: "/gopher/gopherbw.png"},
+		{Path: "/gopher/gophercolor.png"},
+		{Path: "/gopher/gophercolor16x16.png"},
+		{Path: "/gopher/help.png"},
+		{Path: "/gopher/pkg.png"},
+		{Path: "/gopher/project.png"},
+		{Path: "/gopher/ref.png"},
+		{Path: "/gopher/run.png"},
+		{Path: "/gopher/talks.png"},
+		{Path: "/gopher/pencil/"},
+		{Path: "/gopher/pencil/gopherhat.jpg"},
+		{Path: "/gopher/pencil/gopherhelmet.jpg"},
+		{Path: "/gopher/pencil/gophermega.jpg"},
+		{Path: "/gopher/pencil/gopherrunning.jpg"},
+		{Path: "/gopher/pencil/gopherswim.jpg"},
+		{Path: "/gopher/pencil/gopherswrench.jpg"},
+		{Path: "/play/"},
+		{Path: "/play/fib.go"},
+		{Path: "/play/hello.go"},
+		{Path: "/play/life.go"},
+		{Path: "/play/peano.go"},
+		{Path: "/play/pi.go"},
+		{Path: "/play/sieve.go"},
+		{Path: "/play/solitaire.go"},
+		{Path: "/play/tree.go"},
+		{Path: "/progs/"},
+		{Path: "/progs/cgo1.go"},
+		{Path: "/progs/cgo2.go"},
+		{Path: "/progs/cgo3.go"},
+		{Path: "/progs/cgo4.go"},
+		{Path: "/progs/defer.go"},
+		{Path: "/progs/defer.out"},
+		{Path: "/progs/defer2.go"},
+		{Path: "/progs/defer2.out"},
+		{Path: "/progs/eff_bytesize.go"},
+		{Path: "/progs/eff_bytesize.out"},
+		{Path: "/progs/eff_qr.go"},
+		{Path: "/progs/eff_sequence.go"},
+		{Path: "/progs/eff_sequence.out"},
+		{Path: "/progs/eff_unused1.go"},
+		{Path: "/progs/eff_unused2.go"},
+		{Path: "/progs/error.go"},
+		{Path: "/progs/error2.go"},
+		{Path: "/progs/error3.go"},
+		{Path: "/progs/error4.go"},
+		{Path: "/progs/go1.go"},
+		{Path: "/progs/gobs1.go"},
+		{Path: "/progs/gobs2.go"},
+		{Path: "/progs/image_draw.go"},
+		{Path: "/progs/image_package1.go"},
+		{Path: "/progs/image_package1.out"},
+		{Path: "/progs/image_package2.go"},
+		{Path: "/progs/image_package2.out"},
+		{Path: "/progs/image_package3.go"},
+		{Path: "/progs/image_package3.out"},
+		{Path: "/progs/image_package4.go"},
+		{Path: "/progs/image_package4.out"},
+		{Path: "/progs/image_package5.go"},
+		{Path: "/progs/image_package5.out"},
+		{Path: "/progs/image_package6.go"},
+		{Path: "/progs/image_package6.out"},
+		{Path: "/progs/interface.go"},
+		{Path: "/progs/interface2.go"},
+		{Path: "/progs/interface2.out"},
+		{Path: "/progs/json1.go"},
+		{Path: "/progs/json2.go"},
+		{Path: "/progs/json2.out"},
+		{Path: "/progs/json3.go"},
+		{Path: "/progs/json4.go"},
+		{Path: "/progs/json5.go"},
+		{Path: "/progs/run"},
+		{Path: "/progs/slices.go"},
+		{Path: "/progs/timeout1.go"},
+		{Path: "/progs/timeout2.go"},
+		{Path: "/progs/update.bash"},
 	}
 
 	gitHubAPI = []*Route{
-		{"/applications/:client_id/tokens", ""},
-		{"/applications/:client_id/tokens/:access_token", ""},
-		{"/authorizations", ""},
-		{"/authorizations/:id", ""},
-		{"/authorizations/clients/:client_id", ""},
-		{"/emojis", ""},
-		{"/events", ""},
-		{"/feeds", ""},
-		{"/gists", ""},
-		{"/gists/:id", ""},
-		{"/gists/:id/forks", ""},
-		{"/gists/:id/star", ""},
-		{"/gists/public", ""},
-		{"/gists/starred", ""},
-		{"/gitignore/templates", ""},
-		{"/gitignore/templates/:name", ""},
-		{"/issues", ""},
-		{"/legacy/issues/search/:owner/:repository/:state/:keyword", ""},
-		{"/legacy/repos/search/:keyword", ""},
-		{"/legacy/user/email/:email", ""},
-		{"/legacy/user/search/:keyword", ""},
-		{"/markdown", ""},
-		{"/markdown/raw", ""},
-		{"/meta", ""},
-		{"/networks/:owner/:repo/events", ""},
-		{"/notifications", ""},
-		{"/notifications/threads/:id", ""},
-		{"/notifications/threads/:id/subscription", ""},
-		{"/orgs/:org", ""},
-		{"/orgs/:org/events", ""},
-		{"/orgs/:org/issues", ""},
-		{"/orgs/:org/members", ""},
-		{"/orgs/:org/members/:user", ""},
-		{"/orgs/:org/public_members", ""},
-		{"/orgs/:org/public_members/:user", ""},
-		{"/orgs/:org/repos", ""},
-		{"/orgs/:org/teams", ""},
-		{"/rate_limit", ""},
-		{"/repos/:owner/:repo", ""},
-		{"/repos/:owner/:repo/:archive_format/:ref", ""},
-		{"/repos/:owner/:repo/assignees", ""},
-		{"/repos/:owner/:repo/assignees/:assignee", ""},
-		{"/repos/:owner/:repo/branches", ""},
-		{"/repos/:owner/:repo/branches/:branch", ""},
-		{"/repos/:owner/:repo/collaborators", ""},
-		{"/repos/:owner/:repo/collaborators/:user", ""},
-		{"/repos/:owner/:repo/comments", ""},
-		{"/repos/:owner/:repo/comments/:id", ""},
-		{"/repos/:owner/:repo/commits", ""},
-		{"/repos/:owner/:repo/commits/:sha", ""},
-		{"/repos/:owner/:repo/commits/:sha/comments", ""},
-		{"/repos/:owner/:repo/contents/*path", ""},
-		{"/repos/:owner/:repo/contributors", ""},
-		{"/repos/:owner/:repo/downloads", ""},
-		{"/repos/:owner/:repo/downloads/:id", ""},
-		{"/repos/:owner/:repo/events", ""},
-		{"/repos/:owner/:repo/forks", ""},
-		{"/repos/:owner/:repo/git/blobs", ""},
-		{"/repos/:owner/:repo/git/blobs/:sha", ""},
-		{"/repos/:owner/:repo/git/commits", ""},
-		{"/repos/:owner/:repo/git/commits/:sha", ""},
-		{"/repos/:owner/:repo/git/refs", ""},
-		{"/repos/:owner/:repo/git/refs/*ref", ""},
-		{"/repos/:owner/:repo/git/tags", ""},
-		{"/repos/:owner/:repo/git/tags/:sha", ""},
-		{"/repos/:owner/:repo/git/trees", ""},
-		{"/repos/:owner/:repo/git/trees/:sha", ""},
-		{"/repos/:owner/:repo/hooks", ""},
-		{"/repos/:owner/:repo/hooks/:id", ""},
-		{"/repos/:owner/:repo/hooks/:id/tests", ""},
-		{"/repos/:owner/:repo/issues", ""},
-		{"/repos/:owner/:repo/issues/:number", ""},
-		{"/repos/:owner/:repo/issues/:number/comments", ""},
-		{"/repos/:owner/:repo/issues/:number/events", ""},
-		{"/repos/:owner/:repo/issues/:number/labels", ""},
-		{"/repos/:owner/:repo/issues/:number/labels/:name", ""},
-		{"/repos/:owner/:repo/issues/comments", ""},
-		{"/repos/:owner/:repo/issues/comments/:id", ""},
-		{"/repos/:owner/:repo/issues/events", ""},
-		{"/repos/:owner/:repo/issues/events/:id", ""},
-		{"/repos/:owner/:repo/keys", ""},
-		{"/repos/:owner/:repo/keys/:id", ""},
-		{"/repos/:owner/:repo/labels", ""},
-		{"/repos/:owner/:repo/labels/:name", ""},
-		{"/repos/:owner/:repo/languages", ""},
-		{"/repos/:owner/:repo/merges", ""},
-		{"/repos/:owner/:repo/milestones", ""},
-		{"/repos/:owner/:repo/milestones/:number", ""},
-		{"/repos/:owner/:repo/milestones/:number/labels", ""},
-		{"/repos/:owner/:repo/notifications", ""},
-		{"/repos/:owner/:repo/pulls", ""},
-		{"/repos/:owner/:repo/pulls/:number", ""},
-		{"/repos/:owner/:repo/pulls/:number/comments", ""},
-		{"/repos/:owner/:repo/pulls/:number/commits", ""},
-		{"/repos/:owner/:repo/pulls/:number/files", ""},
-		{"/repos/:owner/:repo/pulls/:number/merge", ""},
-		{"/repos/:owner/:repo/pulls/comments", ""},
-		{"/repos/:owner/:repo/pulls/comments/:number", ""},
-		{"/repos/:owner/:repo/readme", ""},
-		{"/repos/:owner/:repo/releases", ""},
-		{"/repos/:owner/:repo/releases/:id", ""},
-		{"/repos/:owner/:repo/releases/:id/assets", ""},
-		{"/repos/:owner/:repo/stargazers", ""},
-		{"/repos/:owner/:repo/stats/code_frequency", ""},
-		{"/repos/:owner/:repo/stats/commit_activity", ""},
-		{"/repos/:owner/:repo/stats/contributors", ""},
-		{"/repos/:owner/:repo/stats/participation", ""},
-		{"/repos/:owner/:repo/stats/punch_card", ""},
-		{"/repos/:owner/:repo/statuses/:ref", ""},
-		{"/repos/:owner/:repo/subscribers", ""},
-		{"/repos/:owner/:repo/subscription", ""},
-		{"/repos/:owner/:repo/tags", ""},
-		{"/repos/:owner/:repo/teams", ""},
-		{"/repositories", ""},
-		{"/search/code", ""},
-		{"/search/issues", ""},
-		{"/search/repositories", ""},
-		{"/search/users", ""},
-		{"/teams/:id", ""},
-		{"/teams/:id/members", ""},
-		{"/teams/:id/members/:user", ""},
-		{"/teams/:id/repos", ""},
-		{"/teams/:id/repos/:owner/:repo", ""},
-		{"/user", ""},
-		{"/user/emails", ""},
-		{"/user/followers", ""},
-		{"/user/following", ""},
-		{"/user/following/:user", ""},
-		{"/user/issues", ""},
-		{"/user/keys", ""},
-		{"/user/keys/:id", ""},
-		{"/user/orgs", ""},
-		{"/user/repos", ""},
-		{"/user/starred", ""},
-		{"/user/starred/:owner/:repo", ""},
-		{"/user/subscriptions", ""},
-		{"/user/subscriptions/:owner/:repo", ""},
-		{"/user/teams", ""},
-		{"/users", ""},
-		{"/users/:user", ""},
-		{"/users/:user/events", ""},
-		{"/users/:user/events/orgs/:org", ""},
-		{"/users/:user/events/public", ""},
-		{"/users/:user/followers", ""},
-		{"/users/:user/following", ""},
-		{"/users/:user/following/:target_user", ""},
-		{"/users/:user/gists", ""},
-		{"/users/:user/keys", ""},
-		{"/users/:user/orgs", ""},
-		{"/users/:user/received_events", ""},
-		{"/users/:user/received_events/public", ""},
-		{"/users/:user/repos", ""},
-		{"/users/:user/starred", ""},
-		{"/users/:user/subscriptions", ""},
+		{Path: "/applications/:client_id/tokens"},
+		{Path: "/applications/:client_id/tokens/:access_token"},
+		{Path: "/authorizations"},
+		{Path: "/authorizations/:id"},
+		{Path: "/authorizations/clients/:client_id"},
+		{Path: "/emojis"},
+		{Path: "/events"},
+		{Path: "/feeds"},
+		{Path: "/gists"},
+		{Path: "/gists/:id"},
+		{Path: "/gists/:id/forks"},
+		{Path: "/gists/:id/star"},
+		{Path: "/gists/public"},
+		{Path: "/gists/starred"},
+		{Path: "/gitignore/templates"},
+		{Path: "/gitignore/templates/:name"},
+		{Path: "/issues"},
+		{Path: "/legacy/issues/search/:owner/:repository/:state/:keyword"},
+		{Path: "/legacy/repos/search/:keyword"},
+		{Path: "/legacy/user/email/:email"},
+		{Path: "/legacy/user/search/:keyword"},
+		{Path: "/markdown"},
+		{Path: "/markdown/raw"},
+		{Path: "/meta"},
+		{Path: "/networks/:owner/:repo/events"},
+		{Path: "/notifications"},
+		{Path: "/notifications/threads/:id"},
+		{Path: "/notifications/threads/:id/subscription"},
+		{Path: "/orgs/:org"},
+		{Path: "/orgs/:org/events"},
+		{Path: "/orgs/:org/issues"},
+		{Path: "/orgs/:org/members"},
+		{Path: "/orgs/:org/members/:user"},
+		{Path: "/orgs/:org/public_members"},
+		{Path: "/orgs/:org/public_members/:user"},
+		{Path: "/orgs/:org/repos"},
+		{Path: "/orgs/:org/teams"},
+		{Path: "/rate_limit"},
+		{Path: "/repos/:owner/:repo"},
+		{Path: "/repos/:owner/:repo/:archive_format/:ref"},
+		{Path: "/repos/:owner/:repo/assignees"},
+		{Path: "/repos/:owner/:repo/assignees/:assignee"},
+		{Path: "/repos/:owner/:repo/branches"},
+		{Path: "/repos/:owner/:repo/branches/:branch"},
+		{Path: "/repos/:owner/:repo/collaborators"},
+		{Path: "/repos/:owner/:repo/collaborators/:user"},
+		{Path: "/repos/:owner/:repo/comments"},
+		{Path: "/repos/:owner/:repo/comments/:id"},
+		{Path: "/repos/:owner/:repo/commits"},
+		{Path: "/repos/:owner/:repo/commits/:sha"},
+		{Path: "/repos/:owner/:repo/commits/:sha/comments"},
+		{Path: "/repos/:owner/:repo/contents/*path"},
+		{Path: "/repos/:owner/:repo/contributors"},
+		{Path: "/repos/:owner/:repo/downloads"},
+		{Path: "/repos/:owner/:repo/downloads/:id"},
+		{Path: "/repos/:owner/:repo/events"},
+		{Path: "/repos/:owner/:repo/forks"},
+		{Path: "/repos/:owner/:repo/git/blobs"},
+		{Path: "/repos/:owner/:repo/git/blobs/:sha"},
+		{Path: "/repos/:owner/:repo/git/commits"},
+		{Path: "/repos/:owner/:repo/git/commits/:sha"},
+		{Path: "/repos/:owner/:repo/git/refs"},
+		{Path: "/repos/:owner/:repo/git/refs/*ref"},
+		{Path: "/repos/:owner/:repo/git/tags"},
+		{Path: "/repos/:owner/:repo/git/tags/:sha"},
+		{Path: "/repos/:owner/:repo/git/trees"},
+		{Path: "/repos/:owner/:repo/git/trees/:sha"},
+		{Path: "/repos/:owner/:repo/hooks"},
+		{Path: "/repos/:owner/:repo/hooks/:id"},
+		{Path: "/repos/:owner/:repo/hooks/:id/tests"},
+		{Path: "/repos/:owner/:repo/issues"},
+		{Path: "/repos/:owner/:repo/issues/:number"},
+		{Path: "/repos/:owner/:repo/issues/:number/comments"},
+		{Path: "/repos/:owner/:repo/issues/:number/events"},
+		{Path: "/repos/:owner/:repo/issues/:number/labels"},
+		{Path: "/repos/:owner/:repo/issues/:number/labels/:name"},
+		{Path: "/repos/:owner/:repo/issues/comments"},
+		{Path: "/repos/:owner/:repo/issues/comments/:id"},
+		{Path: "/repos/:owner/:repo/issues/events"},
+		{Path: "/repos/:owner/:repo/issues/events/:id"},
+		{Path: "/repos/:owner/:repo/keys"},
+		{Path: "/repos/:owner/:repo/keys/:id"},
+		{Path: "/repos/:owner/:repo/labels"},
+		{Path: "/repos/:owner/:repo/labels/:name"},
+		{Path: "/repos/:owner/:repo/languages"},
+		{Path: "/repos/:owner/:repo/merges"},
+		{Path: "/repos/:owner/:repo/milestones"},
+		{Path: "/repos/:owner/:repo/milestones/:number"},
+		{Path: "/repos/:owner/:repo/milestones/:number/labels"},
+		{Path: "/repos/:owner/:repo/notifications"},
+		{Path: "/repos/:owner/:repo/pulls"},
+		{Path: "/repos/:owner/:repo/pulls/:number"},
+		{Path: "/repos/:owner/:repo/pulls/:number/comments"},
+		{Path: "/repos/:owner/:repo/pulls/:number/commits"},
+		{Path: "/repos/:owner/:repo/pulls/:number/files"},
+		{Path: "/repos/:owner/:repo/pulls/:number/merge"},
+		{Path: "/repos/:owner/:repo/pulls/comments"},
+		{Path: "/repos/:owner/:repo/pulls/comments/:number"},
+		{Path: "/repos/:owner/:repo/readme"},
+		{Path: "/repos/:owner/:repo/releases"},
+		{Path: "/repos/:owner/:repo/releases/:id"},
+		{Path: "/repos/:owner/:repo/releases/:id/assets"},
+		{Path: "/repos/:owner/:repo/stargazers"},
+		{Path: "/repos/:owner/:repo/stats/code_frequency"},
+		{Path: "/repos/:owner/:repo/stats/commit_activity"},
+		{Path: "/repos/:owner/:repo/stats/contributors"},
+		{Path: "/repos/:owner/:repo/stats/participation"},
+		{Path: "/repos/:owner/:repo/stats/punch_card"},
+		{Path: "/repos/:owner/:repo/statuses/:ref"},
+		{Path: "/repos/:owner/:repo/subscribers"},
+		{Path: "/repos/:owner/:repo/subscription"},
+		{Path: "/repos/:owner/:repo/tags"},
+		{Path: "/repos/:owner/:repo/teams"},
+		{Path: "/repositories"},
+		{Path: "/search/code"},
+		{Path: "/search/issues"},
+		{Path: "/search/repositories"},
+		{Path: "/search/users"},
+		{Path: "/teams/:id"},
+		{Path: "/teams/:id/members"},
+		{Path: "/teams/:id/members/:user"},
+		{Path: "/teams/:id/repos"},
+		{Path: "/teams/:id/repos/:owner/:repo"},
+		{Path: "/user"},
+		{Path: "/user/emails"},
+		{Path: "/user/followers"},
+		{Path: "/user/following"},
+		{Path: "/user/following/:user"},
+		{Path: "/user/issues"},
+		{Path: "/user/keys"},
+		{Path: "/user/keys/:id"},
+		{Path: "/user/orgs"},
+		{Path: "/user/repos"},
+		{Path: "/user/starred"},
+		{Path: "/user/starred/:owner/:repo"},
+		{Path: "/user/subscriptions"},
+		{Path: "/user/subscriptions/:owner/:repo"},
+		{Path: "/user/teams"},
+		{Path: "/users"},
+		{Path: "/users/:user"},
+		{Path: "/users/:user/events"},
+		{Path: "/users/:user/events/orgs/:org"},
+		{Path: "/users/:user/events/public"},
+		{Path: "/users/:user/followers"},
+		{Path: "/users/:user/following"},
+		{Path: "/users/:user/following/:target_user"},
+		{Path: "/users/:user/gists"},
+		{Path: "/users/:user/keys"},
+		{Path: "/users/:user/orgs"},
+		{Path: "/users/:user/received_events"},
+		{Path: "/users/:user/received_events/public"},
+		{Path: "/users/:user/repos"},
+		{Path: "/users/:user/starred"},
+		{Path: "/users/:user/subscriptions"},
 	}
 
 	parseAPI = []*Route{
-		{"/1/classes/:className", ""},
-		{"/1/classes/:className/:objectId", ""},
-		{"/1/events/:eventName", ""},
-		{"/1/files/:fileName", ""},
-		{"/1/functions", ""},
-		{"/1/installations", ""},
-		{"/1/installations/:objectId", ""},
-		{"/1/login", ""},
-		{"/1/push", ""},
-		{"/1/requestPasswordReset", ""},
-		{"/1/roles", ""},
-		{"/1/roles/:objectId", ""},
-		{"/1/users", ""},
-		{"/1/users/:objectId", ""},
+		{Path: "/1/classes/:className"},
+		{Path: "/1/classes/:className/:objectId"},
+		{Path: "/1/events/:eventName"},
+		{Path: "/1/files/:fileName"},
+		{Path: "/1/functions"},
+		{Path: "/1/installations"},
+		{Path: "/1/installations/:objectId"},
+		{Path: "/1/login"},
+		{Path: "/1/push"},
+		{Path: "/1/requestPasswordReset"},
+		{Path: "/1/roles"},
+		{Path: "/1/roles/:objectId"},
+		{Path: "/1/users"},
+		{Path: "/1/users/:objectId"},
 	}
 
 	googlePlusAPI = []*Route{
 		// People
-		{"/people/:userId", ""},
-		{"/people", ""},
-		{"/activities/:activityId/people/:collection", ""},
-		{"/people/:userId/people/:collection", ""},
-		{"/people/:userId/openIdConnect", ""},
+		{Path: "/people/:userId"},
+		{Path: "/people"},
+		{Path: "/activities/:activityId/people/:collection"},
+		{Path: "/people/:userId/people/:collection"},
+		{Path: "/people/:userId/openIdConnect"},
 
 		// Activities
-		{"/people/:userId/activities/:collection", ""},
-		{"/activities/:activityId", ""},
-		{"/activities", ""},
+		{Path: "/people/:userId/activities/:collection"},
+		{Path: "/activities/:activityId"},
+		{Path: "/activities"},
 
 		// Comments
-		{"/activities/:activityId/comments", ""},
-		{"/comments/:commentId", ""},
+		{Path: "/activities/:activityId/comments"},
+		{Path: "/comments/:commentId"},
 
 		// Moments
-		{"/people/:userId/moments/:collection", ""},
-		{"/people/:userId/moments/:collection", ""},
-		{"/moments/:id", ""},
+		{Path: "/people/:userId/moments/:collection"},
+		{Path: "/people/:userId/moments/:collection"},
+		{Path: "/moments/:id"},
 	}
 
 	// handlerHelper created a function that will set a context key for assertion.
@@ -1097,46 +1098,46 @@ func TestRouterGitHubAPI(t *testing.T) {
 
 func TestRouterParamAlias(t *testing.T) {
 	api := []*Route{
-		{"/users/:userID/following", ""},
-		{"/users/:userID/followedBy", ""},
-		{"/users/:userID/follow", ""},
+		{Path: "/users/:userID/following"},
+		{Path: "/users/:userID/followedBy"},
+		{Path: "/users/:userID/follow"},
 	}
 	testRouterAPI(t, api)
 }
 
 func TestRouterParamOrdering(t *testing.T) {
 	api := []*Route{
-		{"/:a/:b/:c/:id", ""},
-		{"/:a/:id", ""},
-		{"/:a/:g/:id", ""},
+		{Path: "/:a/:b/:c/:id"},
+		{Path: "/:a/:id"},
+		{Path: "/:a/:g/:id"},
 	}
 	testRouterAPI(t, api)
 
 	api2 := []*Route{
-		{"/:a/:id", ""},
-		{"/:a/:g/:id", ""},
-		{"/:a/:b/:c/:id", ""},
+		{Path: "/:a/:id"},
+		{Path: "/:a/:g/:id"},
+		{Path: "/:a/:b/:c/:id"},
 	}
 	testRouterAPI(t, api2)
 
 	api3 := []*Route{
-		{"/:a/:b/:c/:id", ""},
-		{"/:a/:g/:id", ""},
-		{"/:a/:id", ""},
+		{Path: "/:a/:b/:c/:id"},
+		{Path: "/:a/:g/:id"},
+		{Path: "/:a/:id"},
 	}
 	testRouterAPI(t, api3)
 }
 
 func TestRouterMixedParams(t *testing.T) {
 	api := []*Route{
-		{"/teacher/:tid/room/suggestions", ""},
-		{"/teacher/:id", ""},
+		{Path: "/teacher/:tid/room/suggestions"},
+		{Path: "/teacher/:id"},
 	}
 	testRouterAPI(t, api)
 
 	api2 := []*Route{
-		{"/teacher/:id", ""},
-		{"/teacher/:tid/room/suggestions", ""},
+		{Path: "/teacher/:id"},
+		{Path: "/teacher/:tid/room/suggestions"},
 	}
 	testRouterAPI(t, api2)
 }
@@ -1259,3 +1260,211 @@ func BenchmarkRouterParseAPI(b *testing.B) {
 func BenchmarkRouterGooglePlusAPI(b *testing.B) {
 	benchmarkRouterRoutes(b, googlePlusAPI)
 }
+
+func TestRouterParamConstraints(t *testing.T) {
+	is := is.New(t)
+
+	g := New()
+	r := g.router
+
+	r.add("/post/:id<int>", func(c Context) error {
+		c.Set("kind", "id")
+		return nil
+	})
+	r.add("/post/:slug<[a-z-]+>", func(c Context) error {
+		c.Set("kind", "slug")
+		return nil
+	})
+	r.add("/post/:any", func(c Context) error {
+		c.Set("kind", "any")
+		return nil
+	})
+	r.add("/user/:id<uint>/files/*", func(c Context) error {
+		c.Set("kind", "files")
+		return nil
+	})
+	r.add("/tag/:t<[^/.]+>", func(c Context) error {
+		c.Set("kind", "tag")
+		return nil
+	})
+
+	find := func(path string) Context {
+		c := g.newContext(nil, nil, "", nil)
+		r.find(path, c)
+		is.NoErr(c.Handler()(c))
+
+		return c
+	}
+
+	c := find("/post/42")
+	is.Equal("id", c.Get("kind"))
+	is.Equal("/post/:id<int>", c.Path())
+	is.Equal("42", c.Param("id"))
+
+	i, err := c.ParamInt("id")
+	is.NoErr(err)
+	is.Equal(42, i)
+
+	i64, err := c.ParamInt64("id")
+	is.NoErr(err)
+	is.Equal(int64(42), i64)
+
+	c = find("/post/hello-world")
+	is.Equal("slug", c.Get("kind"))
+	is.Equal("hello-world", c.Param("slug"))
+	is.Equal("", c.Param("id"))
+
+	_, err = c.ParamInt("slug")
+	is.Equal(StatusBadRequest, err.(*GeminiError).Code)
+	_, err = c.ParamInt64("slug")
+	is.Equal(StatusBadRequest, err.(*GeminiError).Code)
+
+	c = find("/post/Hello_World")
+	is.Equal("any", c.Get("kind"))
+	is.Equal("Hello_World", c.Param("any"))
+
+	c = find("/user/7/files/a/b.gmi")
+	is.Equal("files", c.Get("kind"))
+	is.Equal("7", c.Param("id"))
+	is.Equal("a/b.gmi", c.Param("*"))
+
+	c = find("/tag/gemini")
+	is.Equal("tag", c.Get("kind"))
+	is.Equal("gemini", c.Param("t"))
+
+	// Unmatched constraints without fallback
+	c = g.newContext(nil, nil, "", nil)
+	r.find("/user/x/files/a", c)
+	is.Equal(ErrNotFound, c.Handler()(c))
+
+	c = g.newContext(nil, nil, "", nil)
+	r.find("/tag/a.b", c)
+	is.Equal(ErrNotFound, c.Handler()(c))
+
+	is.Equal("/tag/x", (&Route{Path: "/tag/:t<[^/.]+>"}).reverse("x"))
+}
+
+func TestRouterParamConstraints_Fallthrough(t *testing.T) {
+	is := is.New(t)
+
+	g := New()
+	r := g.router
+
+	r.add("/post/:id<int>", func(c Context) error {
+		c.Set("kind", "id")
+		return nil
+	})
+	r.add("/post/*", func(c Context) error {
+		c.Set("kind", "any")
+		return nil
+	})
+	r.add("/a/:id<int>", func(c Context) error {
+		c.Set("kind", "a")
+		return nil
+	})
+	r.add("/:x/abc", func(c Context) error {
+		c.Set("kind", "x")
+		return nil
+	})
+	r.add("/b/:id<int>/edit", func(c Context) error {
+		c.Set("kind", "edit")
+		return nil
+	})
+	r.add("/b/:id/*", func(c Context) error {
+		c.Set("kind", "b")
+		return nil
+	})
+
+	find := func(path string) Context {
+		c := g.newContext(nil, nil, "", nil)
+		r.find(path, c)
+		is.NoErr(c.Handler()(c))
+
+		return c
+	}
+
+	// Constraint with any sibling
+	c := find("/post/42")
+	is.Equal("id", c.Get("kind"))
+
+	c = find("/post/abc")
+	is.Equal("any", c.Get("kind"))
+	is.Equal("/post/*", c.Path())
+	is.Equal("abc", c.Param("*"))
+
+	// Constraint with param sibling
+	c = find("/a/1")
+	is.Equal("a", c.Get("kind"))
+
+	c = find("/a/abc")
+	is.Equal("x", c.Get("kind"))
+	is.Equal("/:x/abc", c.Path())
+	is.Equal("a", c.Param("x"))
+
+	// Constraint on a param before static suffix
+	c = find("/b/1/edit")
+	is.Equal("edit", c.Get("kind"))
+
+	c = find("/b/abc/edit")
+	is.Equal("b", c.Get("kind"))
+	is.Equal("abc", c.Param("id"))
+	is.Equal("edit", c.Param("*"))
+
+	// Still not found when nothing else matches
+	c = g.newContext(nil, nil, "", nil)
+	r.find("/a/xyz", c)
+	is.Equal(ErrNotFound, c.Handler()(c))
+}
+
+func TestRouterParamConstraints_Invalid(t *testing.T) {
+	is := is.New(t)
+
+	defer func() {
+		is.True(recover() != nil)
+	}()
+
+	New().router.add("/:id<[>", func(c Context) error { return nil })
+}
+
+func TestRouterConflicts(t *testing.T) {
+	is := is.New(t)
+
+	h := func(Context) error { return nil }
+
+	g := New()
+	g.Handle("/users/:id", h)
+	g.Handle("/users/:id/files", h)
+	g.Handle("/post/:id<int>", h)
+	g.Handle("/post/:slug", h)
+	is.NoErr(g.Validate())
+
+	g.Handle("/users/:name", h)
+	g.Handle("/post/:n<int>", h)
+	g.Handle("/about", h)
+	g.Handle("/about", h)
+	g.Host("example.org").Handle("/about", h)
+	g.Host("example.org").Handle("/about", h)
+
+	err := g.Validate()
+	is.True(errors.Is(err, ErrRouteConflict))
+	is.Equal("route conflict: /users/:name conflicts with /users/:id; "+
+		"/post/:n<int> conflicts with /post/:id<int>; "+
+		"/about is registered twice; "+
+		"example.org/about is registered twice", err.Error())
+
+	// Last route wins
+	c := g.newContext(nil, nil, "", nil).(*context)
+	g.router.find("/users/1", c)
+	is.Equal("/users/:name", c.Path())
+	is.Equal("1", c.Param("name"))
+
+	g = New()
+	g.PanicOnConflict = true
+	g.Handle("/:a", h)
+
+	defer func() {
+		is.Equal("gig: route conflict: /:b conflicts with /:a", recover())
+	}()
+
+	g.Handle("/:b", h)
+}
@@ -4,8 +4,12 @@ package gig
 
 import (
 	"crypto/tls"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
+	"strings"
+	"sync"
 	"syscall"
 	"testing"
 	"time"
@@ -202,3 +206,148 @@ func TestServe_NotURL(t *testing.T) {
 
 	g.Close()
 }
+
+func TestGigLogger(t *testing.T) {
+	is := is.New(t)
+	rec := &recordLogger{}
+	g := New()
+	g.Logger = rec
+	g.HideBanner = true
+
+	go func() {
+		_ = g.Run("127.0.0.1:0", "_fixture/certs/cert.pem", "_fixture/certs/key.pem")
+	}()
+	time.Sleep(200 * time.Millisecond)
+
+	defer g.Close()
+
+	addr := g.listener.Addr().String()
+
+	// Plain text client
+	conn, err := net.Dial("tcp", addr)
+	is.NoErr(err)
+	_, err = conn.Write([]byte("gemini://127.0.0.1/\r\n"))
+	is.NoErr(err)
+	_, _ = conn.Read(make([]byte, 10))
+	conn.Close()
+
+	// Invalid URL
+	tc, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true})
+	is.NoErr(err)
+	_, err = tc.Write([]byte("::::::\r\n"))
+	is.NoErr(err)
+	_, _ = tc.Read(make([]byte, 30))
+	tc.Close()
+
+	time.Sleep(50 * time.Millisecond)
+
+	is.True(rec.has("INFO gemini server started"))
+	is.True(rec.has("WARN TLS handshake failed"))
+	is.True(rec.has("WARN invalid request URL"))
+
+	// Mounted instances use Logger of parent
+	sub := New()
+	g.Mount("/sub", sub)
+	is.Equal(rec, sub.logger())
+}
+
+func TestMetrics_Connections(t *testing.T) {
+	is := is.New(t)
+
+	g := New()
+	g.Metrics = NewMetricsRegistry()
+	g.HideBanner = true
+	g.HidePort = true
+
+	go func() {
+		_ = g.Run("127.0.0.1:0", "_fixture/certs/cert.pem", "_fixture/certs/key.pem")
+	}()
+	time.Sleep(200 * time.Millisecond)
+
+	defer g.Close()
+
+	addr := g.listener.Addr().String()
+
+	// Plain text client fails handshake
+	conn, err := net.Dial("tcp", addr)
+	is.NoErr(err)
+	_, err = conn.Write([]byte("gemini://127.0.0.1/\r\n"))
+	is.NoErr(err)
+	_, _ = conn.Read(make([]byte, 10))
+	conn.Close()
+
+	// Non-gemini scheme
+	tc, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true})
+	is.NoErr(err)
+	_, err = tc.Write([]byte("http://example.com/\r\n"))
+	is.NoErr(err)
+	_, _ = tc.Read(make([]byte, 60))
+	tc.Close()
+
+	time.Sleep(50 * time.Millisecond)
+
+	var buf strings.Builder
+	_, err = g.Metrics.WriteTo(&buf)
+	is.NoErr(err)
+
+	for _, line := range []string{
+		"gig_connections_accepted_total 2\n",
+		"gig_connections_active 0\n",
+		"gig_tls_handshake_failures_total 1\n",
+		"gig_request_errors_total{reason=\"scheme\"} 1\n",
+	} {
+		is.True(strings.Contains(buf.String(), line)) // metrics contain line
+	}
+}
+
+func TestServe_Hooks(t *testing.T) {
+	is := is.New(t)
+	g := New()
+	g.HideBanner = true
+	g.HidePort = true
+
+	var (
+		mu     sync.Mutex
+		events []string
+		record = func(e string) {
+			mu.Lock()
+			events = append(events, e)
+			mu.Unlock()
+		}
+	)
+
+	g.OnAccept = func(conn net.Conn) { record("accept") }
+	g.OnHandshake = func(conn net.Conn, d time.Duration, err error) { record("handshake") }
+	g.OnRequestParsed = func(c Context, d time.Duration) { record("parsed " + c.URL().Path) }
+	g.OnResponseHeader = func(c Context, status Status, meta string, d time.Duration) {
+		record(fmt.Sprintf("header %d %s", status, meta))
+	}
+	g.OnResponseDone = func(c Context, d time.Duration) { record("done " + GetRequestID(c)) }
+	g.OnError = func(c Context, err error) { record("error " + err.Error()) }
+
+	g.Use(RequestIDWithConfig(RequestIDConfig{Generator: func() string { return "id1" }}))
+	g.Handle("/fail", func(c Context) error {
+		return NewError(StatusTemporaryFailure, "oops")
+	})
+
+	go func() {
+		_ = g.Run("127.0.0.1:0", "_fixture/certs/cert.pem", "_fixture/certs/key.pem")
+	}()
+	time.Sleep(200 * time.Millisecond)
+
+	defer g.Close()
+
+	conn, err := tls.Dial("tcp", g.listener.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+	is.NoErr(err)
+	_, err = conn.Write([]byte("gemini://127.0.0.1/fail\r\n"))
+	is.NoErr(err)
+	_, _ = ioutil.ReadAll(conn)
+	conn.Close()
+
+	time.Sleep(50 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	is.Equal([]string{"accept", "handshake", "parsed /fail", "error error=oops", "header 40 oops", "done id1"}, events)
+}
@@ -0,0 +1,403 @@
+package gig
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type (
+	// SessionData holds values of a client certificate that outlive a single
+	// request. Values are saved by Session middleware after the handler
+	// returns. SessionData is not safe for concurrent use, concurrent requests
+	// using the same certificate overwrite each other's changes.
+	SessionData struct {
+		// ID identifies session, it is derived from certificate fingerprint.
+		ID string
+
+		// Values are application specific values. Custom types stored in
+		// SessionFileStore must be registered with `gob.Register()`.
+		Values map[string]interface{}
+
+		// FlashMessages are messages to be shown on the next page, see
+		// `AddFlash()`.
+		FlashMessages []string
+
+		// Expires is the time session expires, unless it is used again.
+		Expires time.Time
+
+		changed bool
+	}
+
+	// SessionStore loads and saves sessions. Implementations must be safe for
+	// concurrent use.
+	SessionStore interface {
+		// Load returns session with id, or nil if there is none or it
+		// expired.
+		Load(id string) (*SessionData, error)
+
+		// Save creates or updates session.
+		Save(session *SessionData) error
+
+		// Delete removes session with id.
+		Delete(id string) error
+	}
+
+	// SessionConfig defines the config for Session middleware.
+	SessionConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Store keeps sessions.
+		// Required.
+		Store SessionStore
+
+		// Fingerprint defines how client certificate is fingerprinted.
+		// Optional. Default value FingerprintSHA256.
+		Fingerprint FingerprintAlgorithm
+
+		// PerHost keeps separate sessions for every host a certificate is
+		// used with, instead of one session per certificate.
+		// Optional. Default value false.
+		PerHost bool
+
+		// TTL is how long session lives after its last use.
+		// Optional. Default value 24 hours.
+		TTL time.Duration
+
+		// Optional makes requests without client certificate pass through
+		// without session instead of being rejected.
+		// Optional. Default value false.
+		Optional bool
+	}
+
+	// SessionMemoryStore is a SessionStore that keeps sessions in memory,
+	// evicting expired ones.
+	SessionMemoryStore struct {
+		mu        sync.Mutex
+		sessions  map[string]*SessionData
+		nextSweep time.Time
+	}
+
+	// SessionFileStore is a SessionStore that keeps every session in a gob
+	// encoded file in a directory.
+	SessionFileStore struct {
+		dir string
+	}
+)
+
+const sessionKey = "session"
+
+var (
+	// DefaultSessionConfig is the default Session middleware config.
+	DefaultSessionConfig = SessionConfig{
+		Skipper:     DefaultSkipper,
+		Fingerprint: FingerprintSHA256,
+		TTL:         24 * time.Hour,
+	}
+)
+
+// Session returns a middleware that requires a client certificate and loads
+// session of it into context, see `GetSession()`. Session is saved after the
+// handler returns if it was changed.
+func Session(store SessionStore) MiddlewareFunc {
+	c := DefaultSessionConfig
+	c.Store = store
+
+	return SessionWithConfig(c)
+}
+
+// SessionWithConfig returns a Session middleware with config.
+// See `Session()`.
+func SessionWithConfig(config SessionConfig) MiddlewareFunc {
+	// Defaults
+	if config.Store == nil {
+		panic("gig: session middleware requires a store")
+	}
+
+	if config.Skipper == nil {
+		config.Skipper = DefaultSessionConfig.Skipper
+	}
+
+	if config.TTL == 0 {
+		config.TTL = DefaultSessionConfig.TTL
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			fp := c.CertFingerprint(config.Fingerprint)
+			if fp == "" {
+				if config.Optional {
+					return next(c)
+				}
+
+				return ErrClientCertificateRequired
+			}
+
+			id := fp
+			if config.PerHost {
+				sum := sha256.Sum256([]byte(fp + "\x00" + c.URL().Hostname()))
+				id = hex.EncodeToString(sum[:])
+			}
+
+			s, err := config.Store.Load(id)
+			if err != nil {
+				return err
+			}
+
+			if s == nil {
+				s = &SessionData{ID: id}
+			}
+
+			c.Set(sessionKey, s)
+
+			err = next(c)
+
+			if serr := config.save(s); serr != nil && err == nil {
+				err = serr
+			}
+
+			return err
+		}
+	}
+}
+
+// save saves changed session, or extends expiry of session that is past half
+// of its TTL. Empty sessions are deleted.
+func (config *SessionConfig) save(s *SessionData) error {
+	if len(s.Values) == 0 && len(s.FlashMessages) == 0 {
+		if s.changed {
+			return config.Store.Delete(s.ID)
+		}
+
+		return nil
+	}
+
+	now := time.Now()
+	if !s.changed && s.Expires.Sub(now) > config.TTL/2 {
+		return nil
+	}
+
+	s.Expires = now.Add(config.TTL)
+	s.changed = false
+
+	return config.Store.Save(s)
+}
+
+// GetSession returns session loaded by Session middleware, or nil.
+func GetSession(c Context) *SessionData {
+	s, _ := c.Get(sessionKey).(*SessionData)
+	return s
+}
+
+// Get returns value of key.
+func (s *SessionData) Get(key string) interface{} {
+	return s.Values[key]
+}
+
+// Set sets value of key.
+func (s *SessionData) Set(key string, val interface{}) {
+	if s.Values == nil {
+		s.Values = map[string]interface{}{}
+	}
+
+	s.Values[key] = val
+	s.changed = true
+}
+
+// Delete removes key.
+func (s *SessionData) Delete(key string) {
+	delete(s.Values, key)
+	s.changed = true
+}
+
+// Clear removes all values and flash messages.
+func (s *SessionData) Clear() {
+	s.Values = nil
+	s.FlashMessages = nil
+	s.changed = true
+}
+
+// AddFlash adds a message to be returned by `Flashes()` on a following
+// request.
+func (s *SessionData) AddFlash(msg string) {
+	s.FlashMessages = append(s.FlashMessages, msg)
+	s.changed = true
+}
+
+// Flashes returns flash messages and removes them from session.
+func (s *SessionData) Flashes() []string {
+	msgs := s.FlashMessages
+	if len(msgs) > 0 {
+		s.FlashMessages = nil
+		s.changed = true
+	}
+
+	return msgs
+}
+
+// copy returns a copy of session that does not share values with it.
+func (s *SessionData) copy() *SessionData {
+	cp := *s
+
+	if s.Values != nil {
+		cp.Values = make(map[string]interface{}, len(s.Values))
+		for k, v := range s.Values {
+			cp.Values[k] = v
+		}
+	}
+
+	cp.FlashMessages = append([]string(nil), s.FlashMessages...)
+
+	return &cp
+}
+
+// NewSessionMemoryStore returns an empty SessionMemoryStore.
+func NewSessionMemoryStore() *SessionMemoryStore {
+	return &SessionMemoryStore{sessions: map[string]*SessionData{}}
+}
+
+// Load implements SessionStore.
+func (s *SessionMemoryStore) Load(id string) (*SessionData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess := s.sessions[id]
+	if sess == nil {
+		return nil, nil
+	}
+
+	if time.Now().After(sess.Expires) {
+		delete(s.sessions, id)
+		return nil, nil
+	}
+
+	return sess.copy(), nil
+}
+
+// Save implements SessionStore.
+func (s *SessionMemoryStore) Save(session *SessionData) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.After(s.nextSweep) {
+		for id, sess := range s.sessions {
+			if now.After(sess.Expires) {
+				delete(s.sessions, id)
+			}
+		}
+
+		s.nextSweep = now.Add(time.Minute)
+	}
+
+	s.sessions[session.ID] = session.copy()
+
+	return nil
+}
+
+// Delete implements SessionStore.
+func (s *SessionMemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, id)
+
+	return nil
+}
+
+// Len returns number of sessions kept, including expired ones that were not
+// evicted yet.
+func (s *SessionMemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.sessions)
+}
+
+// NewSessionFileStore returns a SessionFileStore that keeps sessions in dir,
+// creating it if needed. Expired sessions are removed when loaded, or by
+// `Sweep()`.
+func NewSessionFileStore(dir string) (*SessionFileStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &SessionFileStore{dir: dir}, nil
+}
+
+// Load implements SessionStore.
+func (s *SessionFileStore) Load(id string) (*SessionData, error) {
+	b, err := ioutil.ReadFile(s.file(id))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var sess SessionData
+	if err = gob.NewDecoder(bytes.NewReader(b)).Decode(&sess); err != nil {
+		return nil, err
+	}
+
+	if time.Now().After(sess.Expires) {
+		return nil, s.Delete(id)
+	}
+
+	return &sess, nil
+}
+
+// Save implements SessionStore.
+func (s *SessionFileStore) Save(session *SessionData) error {
+	var b bytes.Buffer
+	if err := gob.NewEncoder(&b).Encode(session); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.file(session.ID), b.Bytes())
+}
+
+// Delete implements SessionStore.
+func (s *SessionFileStore) Delete(id string) error {
+	err := os.Remove(s.file(id))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+// Sweep removes expired sessions.
+func (s *SessionFileStore) Sweep() error {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.session"))
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		id := filepath.Base(f)
+		id = id[:len(id)-len(".session")]
+
+		if _, err := s.Load(id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *SessionFileStore) file(id string) string {
+	return filepath.Join(s.dir, id+".session")
+}
@@ -0,0 +1,151 @@
+package gig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestSession(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gig-session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files, err := NewSessionFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, store := range map[string]SessionStore{
+		"memory": NewSessionMemoryStore(),
+		"file":   files,
+	} {
+		t.Run(name, func(t *testing.T) {
+			is := is.New(t)
+			g := New()
+			g.Use(Session(store))
+
+			g.Handle("/add/:item", func(c Context) error {
+				s := GetSession(c)
+				cart, _ := s.Get("cart").(string)
+				s.Set("cart", cart+c.Param("item")+";")
+				s.AddFlash("Added " + c.Param("item"))
+
+				return c.NoContent(StatusRedirectTemporary, "/")
+			})
+			g.Handle("/clear", func(c Context) error {
+				GetSession(c).Clear()
+				return c.NoContent(StatusRedirectTemporary, "/")
+			})
+			g.Handle("/", func(c Context) error {
+				s := GetSession(c)
+				return c.Text("%v %s", s.Get("cart"), strings.Join(s.Flashes(), ","))
+			})
+
+			var (
+				alice = &x509.Certificate{Raw: []byte{1}}
+				bob   = &x509.Certificate{Raw: []byte{2}}
+			)
+
+			req := func(path string, cert *x509.Certificate) string {
+				var state *tls.ConnectionState
+				if cert != nil {
+					state = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}
+				}
+
+				c, conn := g.NewFakeContext(path, state)
+				g.ServeGemini(c)
+
+				return conn.Written
+			}
+
+			is.Equal("60 Client Certificate Required\r\n", req("/", nil))
+			is.Equal("20 text/plain\r\n<nil> ", req("/", alice))
+
+			req("/add/apple", alice)
+			req("/add/pear", alice)
+			req("/add/plum", bob)
+
+			is.Equal("20 text/plain\r\napple;pear; Added apple,Added pear", req("/", alice))
+			is.Equal("20 text/plain\r\napple;pear; ", req("/", alice)) // Flashes are shown once
+			is.Equal("20 text/plain\r\nplum; Added plum", req("/", bob))
+
+			req("/clear", alice)
+			is.Equal("20 text/plain\r\n<nil> ", req("/", alice))
+
+			s, err := store.Load(CertFingerprint(alice, FingerprintSHA256))
+			is.NoErr(err)
+			is.Equal(nil, s) // Empty session is deleted
+		})
+	}
+}
+
+func TestSession_PerHost(t *testing.T) {
+	var (
+		is    = is.New(t)
+		g     = New()
+		cert  = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Raw: []byte{1}}}}
+		store = NewSessionMemoryStore()
+	)
+
+	g.Use(SessionWithConfig(SessionConfig{Store: store, PerHost: true}))
+	g.Handle("/*", func(c Context) error {
+		s := GetSession(c)
+		n, _ := s.Get("n").(int)
+		s.Set("n", n+1)
+
+		return c.Text("%d", n+1)
+	})
+
+	req := func(uri string) string {
+		c, conn := g.NewFakeContext(uri, cert)
+		g.ServeGemini(c)
+
+		return conn.Written
+	}
+
+	is.Equal("20 text/plain\r\n1", req("gemini://a.example/"))
+	is.Equal("20 text/plain\r\n2", req("gemini://a.example/"))
+	is.Equal("20 text/plain\r\n1", req("gemini://b.example/"))
+	is.Equal(2, store.Len())
+}
+
+func TestSessionStore_Expiry(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := ioutil.TempDir("", "gig-session")
+	is.NoErr(err)
+	defer os.RemoveAll(dir)
+
+	files, err := NewSessionFileStore(dir)
+	is.NoErr(err)
+
+	for _, store := range []SessionStore{NewSessionMemoryStore(), files} {
+		is.NoErr(store.Save(&SessionData{ID: "old", Values: map[string]interface{}{"a": 1}, Expires: time.Now().Add(-time.Second)}))
+		is.NoErr(store.Save(&SessionData{ID: "new", Values: map[string]interface{}{"a": 1}, Expires: time.Now().Add(time.Hour)}))
+
+		s, err := store.Load("old")
+		is.NoErr(err)
+		is.Equal(nil, s)
+
+		s, err = store.Load("new")
+		is.NoErr(err)
+		is.Equal(1, s.Get("a"))
+	}
+
+	is.NoErr(files.Save(&SessionData{ID: "stale", Expires: time.Now().Add(-time.Second)}))
+	is.NoErr(files.Sweep())
+
+	left, err := filepath.Glob(filepath.Join(dir, "*"))
+	is.NoErr(err)
+	is.Equal([]string{filepath.Join(dir, "new.session")}, left)
+}
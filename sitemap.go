@@ -0,0 +1,181 @@
+package gig
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// SitemapConfig defines the config for Sitemap handler.
+	SitemapConfig struct {
+		// Title is rendered as the page heading.
+		// Optional. Default value "Sitemap".
+		Title string
+
+		// DisableFiles disables listing of files served by Static routes.
+		// Optional. Default value false.
+		DisableFiles bool
+
+		// CacheDuration is how long listing of files served by Static routes
+		// is reused before directories are walked again.
+		// Optional. Default value 1 minute. Negative value disables caching.
+		CacheDuration time.Duration
+	}
+
+	// sitemapCache keeps listings of Static roots.
+	sitemapCache struct {
+		mu    sync.Mutex
+		files map[string]*sitemapFiles // By root
+	}
+
+	sitemapFiles struct {
+		names  []string
+		listed time.Time
+	}
+)
+
+var (
+	// DefaultSitemapConfig is the default Sitemap handler config.
+	DefaultSitemapConfig = SitemapConfig{
+		Title:         "Sitemap",
+		CacheDuration: time.Minute,
+	}
+)
+
+// Sitemap returns a handler that renders a gemtext list of all routes without
+// parameters, followed by files reachable via Static routes. Hidden routes and
+// routes of mounted Gig instances are not listed.
+func Sitemap() HandlerFunc {
+	return SitemapWithConfig(DefaultSitemapConfig)
+}
+
+// SitemapWithConfig returns a Sitemap handler with config.
+// See: `Sitemap()`.
+func SitemapWithConfig(config SitemapConfig) HandlerFunc {
+	// Defaults
+	if config.Title == "" {
+		config.Title = DefaultSitemapConfig.Title
+	}
+
+	if config.CacheDuration == 0 {
+		config.CacheDuration = DefaultSitemapConfig.CacheDuration
+	}
+
+	cache := &sitemapCache{files: map[string]*sitemapFiles{}}
+
+	return func(c Context) error {
+		var (
+			buf    = new(bytes.Buffer)
+			routes = c.Gig().Routes()
+			prefix = c.Gig().mountPath()
+			host   = strings.ToLower(c.URL().Hostname())
+		)
+
+		fmt.Fprintf(buf, "# %s\n\n", config.Title)
+
+		for _, r := range routes {
+			if r.Hidden || r.mount || !r.IsStatic() || !r.matchHost(host) {
+				continue
+			}
+
+			text := r.Title
+			if text == "" {
+				text = r.Path
+			}
+
+			if r.Description != "" {
+				text += " - " + r.Description
+			}
+
+			fmt.Fprintf(buf, "=> %s%s %s\n", prefix, r.Path, text)
+		}
+
+		if !config.DisableFiles {
+			for _, r := range routes {
+				if r.Hidden || r.root == "" || !r.matchHost(host) {
+					continue
+				}
+
+				names, err := cache.list(r.root, config.CacheDuration)
+				if err != nil {
+					return err
+				}
+
+				dir := prefix + strings.TrimSuffix(r.Path, "*")
+				if !strings.HasSuffix(dir, "/") {
+					dir += "/"
+				}
+
+				for _, name := range names {
+					fmt.Fprintf(buf, "=> %s%s %s\n", dir, escapeWildcard(name), name)
+				}
+			}
+		}
+
+		return c.GeminiBlob(buf.Bytes())
+	}
+}
+
+// list returns files under root, walking it again if listing is older than
+// ttl.
+func (s *sitemapCache) list(root string, ttl time.Duration) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if f, ok := s.files[root]; ok && time.Since(f.listed) < ttl {
+		return f.names, nil
+	}
+
+	names, err := listSitemapFiles(root)
+	if err != nil {
+		return nil, err
+	}
+
+	s.files[root] = &sitemapFiles{names: names, listed: time.Now()}
+
+	return names, nil
+}
+
+// listSitemapFiles returns slash separated paths of readable non-hidden files
+// under root, relative to it.
+func listSitemapFiles(root string) ([]string, error) {
+	var names []string
+
+	err := filepath.Walk(root, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if strings.HasPrefix(info.Name(), ".") && name != root {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if info.IsDir() || uint64(info.Mode().Perm())&0444 != 0444 {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, name)
+		if err != nil {
+			return err
+		}
+
+		names = append(names, filepath.ToSlash(rel))
+
+		return nil
+	})
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	return names, err
+}
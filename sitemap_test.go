@@ -0,0 +1,69 @@
+package gig
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestSitemap(t *testing.T) {
+	is := is.New(t)
+
+	g := New()
+	h := func(c Context) error { return nil }
+
+	g.Handle("/", h).SetTitle("Home")
+	g.Handle("/about", h).SetTitle("About").SetDescription("Who we are")
+	g.Handle("/secret", h).SetHidden(true)
+	g.Handle("/users/:name", h)
+	g.Handle("/sitemap", Sitemap())
+	g.Static("/docs", "_fixture/folder")
+	g.Static("/missing", "_fixture/missing")
+	g.Static("/hidden", "_fixture/images").SetHidden(true)
+	g.Group("/group").Static("/images", "_fixture/images")
+	g.Mount("/blog", New())
+
+	is.Equal(
+		"20 text/gemini\r\n# Sitemap\n\n"+
+			"=> / Home\n"+
+			"=> /about About - Who we are\n"+
+			"=> /sitemap /sitemap\n"+
+			"=> /docs/about.gmi about.gmi\n"+
+			"=> /docs/another.blah another.blah\n"+
+			"=> /group/images/walle.png walle.png\n",
+		request("/sitemap", g))
+
+	g.Handle("/sitemap", SitemapWithConfig(SitemapConfig{Title: "Map", DisableFiles: true}))
+	is.Equal(
+		"20 text/gemini\r\n# Map\n\n"+
+			"=> / Home\n"+
+			"=> /about About - Who we are\n"+
+			"=> /sitemap /sitemap\n",
+		request("/sitemap", g))
+}
+
+func TestSitemap_Cache(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := ioutil.TempDir("", "gig-sitemap")
+	is.NoErr(err)
+	defer os.RemoveAll(dir)
+
+	is.NoErr(ioutil.WriteFile(filepath.Join(dir, "a.gmi"), nil, 0644))
+
+	g := New()
+	g.Static("/", dir)
+	g.Handle("/sitemap", SitemapWithConfig(SitemapConfig{CacheDuration: time.Hour})).SetHidden(true)
+	g.Handle("/fresh", SitemapWithConfig(SitemapConfig{CacheDuration: -1})).SetHidden(true)
+
+	is.Equal("20 text/gemini\r\n# Sitemap\n\n=> /a.gmi a.gmi\n", request("/sitemap", g))
+
+	is.NoErr(ioutil.WriteFile(filepath.Join(dir, "b.gmi"), nil, 0644))
+
+	is.Equal("20 text/gemini\r\n# Sitemap\n\n=> /a.gmi a.gmi\n", request("/sitemap", g))
+	is.Equal("20 text/gemini\r\n# Sitemap\n\n=> /a.gmi a.gmi\n=> /b.gmi b.gmi\n", request("/fresh", g))
+}
@@ -0,0 +1,389 @@
+package gig
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// PassAuthTOTPStore is a PassAuthStore that also keeps TOTP secrets and
+	// recovery codes of users.
+	PassAuthTOTPStore interface {
+		PassAuthStore
+
+		// TOTPSecret returns base32 encoded TOTP secret of username, or empty
+		// string if user has not enrolled.
+		TOTPSecret(username string) (string, error)
+
+		// SetTOTPSecret sets TOTP secret of username, empty secret disables
+		// TOTP.
+		SetTOTPSecret(username, secret string) error
+
+		// SetRecoveryCodes replaces recovery codes of username.
+		SetRecoveryCodes(username string, codes []string) error
+
+		// UseRecoveryCode reports whether code is a recovery code of
+		// username, and if so, removes it.
+		UseRecoveryCode(username, code string) (bool, error)
+	}
+
+	// totpGuard rejects TOTP codes that were already used.
+	totpGuard struct {
+		mu   sync.Mutex
+		last map[string]uint64 // Username to last used time step
+	}
+)
+
+const (
+	totpPeriod    = 30
+	totpDigits    = 6
+	recoveryCodes = 10
+)
+
+var base32NoPadding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewTOTPSecret returns a random base32 encoded TOTP secret.
+func NewTOTPSecret() string {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return base32NoPadding.EncodeToString(b)
+}
+
+// TOTPCode returns RFC 6238 code of base32 encoded secret at time t, using
+// SHA-1, 6 digits and 30 second period as most authenticator apps do.
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return hotp(key, uint64(t.Unix()/totpPeriod)), nil
+}
+
+// ValidateTOTP reports whether code is valid for secret at time t, accepting
+// codes of one period before and after to allow for clock drift.
+func ValidateTOTP(secret, code string, t time.Time) bool {
+	_, ok := matchTOTP(secret, code, t)
+	return ok
+}
+
+// TOTPURI returns otpauth URI of secret that authenticator apps can import.
+func TOTPURI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	q := url.Values{"secret": {secret}, "issuer": {issuer}}
+
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+// NewRecoveryCodes returns n random recovery codes.
+func NewRecoveryCodes(n int) []string {
+	codes := make([]string, n)
+	for i := range codes {
+		codes[i] = randomID()[:10]
+	}
+
+	return codes
+}
+
+// PassAuthTOTPHandle sets up handlers to enrol user that certificate is pinned
+// to in TOTP. Visiting path shows a new secret and otpauth URI, entering a
+// valid code at path/verify enables TOTP and shows recovery codes. Entering a
+// valid code at path/disable disables TOTP. Config store must implement
+// PassAuthTOTPStore.
+//
+// Enrolled users are asked for a code after password by login handler when
+// `PassAuthLoginConfig.TOTP` is set.
+func (g *Gig) PassAuthTOTPHandle(path string, config PassAuthAccountConfig) {
+	config.defaults()
+
+	store, ok := config.Store.(PassAuthTOTPStore)
+	if !ok {
+		panic("gig: pass auth TOTP handler requires a PassAuthTOTPStore")
+	}
+
+	var (
+		prompts = newPassAuthPrompts()
+		guard   = newTOTPGuard()
+	)
+
+	g.Handle(path, func(c Context) error {
+		sig, username, err := config.user(c)
+		if sig == "" || err != nil {
+			return err
+		}
+
+		secret, err := store.TOTPSecret(username)
+		if err != nil {
+			return err
+		}
+
+		if secret != "" {
+			return c.Gemini("# Two-factor authentication\n\nTwo-factor authentication is enabled.\n\n=> %s/disable Disable", path)
+		}
+
+		// Secret is kept as pending password until it is confirmed
+		secret = NewTOTPSecret()
+		prompts.set(sig, username, secret)
+
+		issuer := c.URL().Hostname()
+		if issuer == "" {
+			issuer = "gig"
+		}
+
+		return c.Gemini("# Two-factor authentication\n\nAdd this secret to your authenticator app:\n\n```\n%s\n```\n\n=> %s Open in authenticator app\n=> %s/verify Enter code to confirm",
+			secret, TOTPURI(issuer, username, secret), path)
+	})
+
+	g.Handle(path+"/verify", func(c Context) error {
+		sig, username, err := config.user(c)
+		if sig == "" || err != nil {
+			return err
+		}
+
+		code, err := c.QueryString()
+		if err != nil {
+			return c.NoContent(StatusBadRequest, "Invalid code received")
+		}
+
+		if code == "" {
+			return c.NoContent(StatusInput, "Enter code from authenticator app")
+		}
+
+		p := prompts.take(sig)
+		if p == nil || p.username != username {
+			return c.NoContent(StatusRedirectTemporary, "%s", path)
+		}
+
+		if locked, err := config.Limiter.slowDown(username, sig, c); locked {
+			prompts.put(sig, p)
+			return err
+		}
+
+		defer config.Limiter.release(username, sig, c.IP())
+
+		if !guard.check(username, p.password, code) {
+			config.Limiter.Fail(username, sig, ErrInvalidCredentials, c)
+
+			if !prompts.retry(sig, p) {
+				return c.NoContent(StatusRedirectTemporary, "%s", path)
+			}
+
+			return c.NoContent(StatusInput, "Invalid code, try again")
+		}
+
+		config.Limiter.Succeed(username, sig)
+
+		codes := NewRecoveryCodes(recoveryCodes)
+
+		if err := store.SetTOTPSecret(username, p.password); err != nil {
+			return err
+		}
+
+		if err := store.SetRecoveryCodes(username, codes); err != nil {
+			return err
+		}
+
+		return c.Gemini("# Two-factor authentication enabled\n\nKeep these recovery codes safe, each can be used once instead of a code:\n\n```\n%s\n```\n",
+			strings.Join(codes, "\n"))
+	})
+
+	g.Handle(path+"/disable", func(c Context) error {
+		sig, username, err := config.user(c)
+		if sig == "" || err != nil {
+			return err
+		}
+
+		secret, err := store.TOTPSecret(username)
+		if err != nil {
+			return err
+		}
+
+		if secret == "" {
+			// Not enrolled, nothing to disable
+			return c.NoContent(StatusRedirectTemporary, "%s", path)
+		}
+
+		code, err := c.QueryString()
+		if err != nil {
+			return c.NoContent(StatusBadRequest, "Invalid code received")
+		}
+
+		if code == "" {
+			return c.NoContent(StatusInput, "Enter code from authenticator app")
+		}
+
+		if locked, err := config.Limiter.slowDown(username, sig, c); locked {
+			return err
+		}
+
+		defer config.Limiter.release(username, sig, c.IP())
+
+		if !guard.check(username, secret, code) {
+			config.Limiter.Fail(username, sig, ErrInvalidCredentials, c)
+			return c.NoContent(StatusInput, "Invalid code, try again")
+		}
+
+		config.Limiter.Succeed(username, sig)
+
+		if err := store.SetTOTPSecret(username, ""); err != nil {
+			return err
+		}
+
+		if err := store.SetRecoveryCodes(username, nil); err != nil {
+			return err
+		}
+
+		return c.Gemini("# Two-factor authentication disabled")
+	})
+}
+
+// verifyTOTP checks code or recovery code of user.
+func verifyTOTP(store PassAuthTOTPStore, guard *totpGuard, username, secret, code string) (bool, error) {
+	if guard.check(username, secret, code) {
+		return true, nil
+	}
+
+	return store.UseRecoveryCode(username, code)
+}
+
+func newTOTPGuard() *totpGuard {
+	return &totpGuard{last: map[string]uint64{}}
+}
+
+// check validates code, rejecting codes of time steps already used by
+// username.
+func (g *totpGuard) check(username, secret, code string) bool {
+	step, ok := matchTOTP(secret, code, time.Now())
+	if !ok {
+		return false
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if last, ok := g.last[username]; ok && step <= last {
+		return false
+	}
+
+	g.last[username] = step
+
+	return true
+}
+
+func matchTOTP(secret, code string, t time.Time) (uint64, bool) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil || len(code) != totpDigits {
+		return 0, false
+	}
+
+	step := uint64(t.Unix() / totpPeriod)
+
+	for _, s := range []uint64{step - 1, step, step + 1} {
+		if hmac.Equal([]byte(hotp(key, s)), []byte(code)) {
+			return s, true
+		}
+	}
+
+	return 0, false
+}
+
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.Replace(secret, " ", "", -1))
+	return base32NoPadding.DecodeString(strings.TrimRight(secret, "="))
+}
+
+// hotp returns RFC 4226 code of key for counter.
+func hotp(key []byte, counter uint64) string {
+	var msg [8]byte
+
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	off := sum[len(sum)-1] & 0xf
+	v := binary.BigEndian.Uint32(sum[off:off+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%06d", v%1000000)
+}
+
+// TOTPSecret implements PassAuthTOTPStore.
+func (s *PassAuthMemoryStore) TOTPSecret(username string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.totp[username], nil
+}
+
+// SetTOTPSecret implements PassAuthTOTPStore.
+func (s *PassAuthMemoryStore) SetTOTPSecret(username, secret string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[username]; !ok {
+		return ErrInvalidCredentials
+	}
+
+	if secret == "" {
+		delete(s.totp, username)
+	} else {
+		s.totp[username] = secret
+	}
+
+	return nil
+}
+
+// SetRecoveryCodes implements PassAuthTOTPStore.
+func (s *PassAuthMemoryStore) SetRecoveryCodes(username string, codes []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[username]; !ok {
+		return ErrInvalidCredentials
+	}
+
+	hashes := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		hashes[hashRecoveryCode(code)] = true
+	}
+
+	s.recovery[username] = hashes
+
+	return nil
+}
+
+// UseRecoveryCode implements PassAuthTOTPStore.
+func (s *PassAuthMemoryStore) UseRecoveryCode(username, code string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h := hashRecoveryCode(code)
+	if !s.recovery[username][h] {
+		return false, nil
+	}
+
+	delete(s.recovery[username], h)
+
+	return true, nil
+}
+
+// hashRecoveryCode hashes random recovery codes, which unlike passwords do
+// not need a slow hash.
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(code)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,147 @@
+package gig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestTOTPCode(t *testing.T) {
+	is := is.New(t)
+
+	// RFC 6238 test vectors, truncated to 6 digits
+	secret := base32NoPadding.EncodeToString([]byte("12345678901234567890"))
+
+	for unix, expected := range map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	} {
+		code, err := TOTPCode(secret, time.Unix(unix, 0))
+		is.NoErr(err)
+		is.Equal(expected, code)
+	}
+
+	now := time.Unix(1234567890, 0)
+	is.True(ValidateTOTP(secret, "005924", now))
+	is.True(ValidateTOTP(strings.ToLower(secret), "005924", now.Add(30*time.Second)))
+	is.True(!ValidateTOTP(secret, "005924", now.Add(time.Minute)))
+	is.True(!ValidateTOTP(secret, "5924", now))
+	is.True(!ValidateTOTP("not base32!", "005924", now))
+
+	_, err := TOTPCode("1", now)
+	is.True(err != nil)
+
+	is.Equal(32, len(NewTOTPSecret()))
+	is.Equal("otpauth://totp/example.org:jon%20snow?issuer=example.org&secret=ABC", TOTPURI("example.org", "jon snow", "ABC"))
+}
+
+func TestPassAuthTOTP(t *testing.T) {
+	var (
+		is     = is.New(t)
+		g      = New()
+		store  = NewPassAuthMemoryStore()
+		laptop = &x509.Certificate{Raw: []byte{1}}
+		phone  = &x509.Certificate{Raw: []byte{2}}
+		req    = func(path string, cert *x509.Certificate) string {
+			c, res := g.NewFakeContext(path, &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}})
+			g.ServeGemini(c)
+
+			return res.Written
+		}
+	)
+
+	store.Cost = bcrypt.MinCost
+	is.NoErr(store.Register("jon", "winteriscoming"))
+	is.NoErr(store.Pin("jon", CertFingerprint(laptop, FingerprintSHA256)))
+
+	g.PassAuthLoginHandleWithConfig("/login", PassAuthLoginConfig{
+		Login: PassAuthStoreLogin(store, "/private"),
+		TOTP:  store,
+	})
+	g.PassAuthTOTPHandle("/2fa", PassAuthAccountConfig{Store: store})
+
+	// Without TOTP password is enough
+	is.Equal("30 /private\r\n", req("/login/jon?winteriscoming", phone))
+	is.NoErr(store.Unpin(CertFingerprint(phone, FingerprintSHA256)))
+
+	// Nothing to disable before enrolment
+	is.Equal("30 /2fa\r\n", req("/2fa/disable", laptop))
+	is.Equal("30 /2fa\r\n", req("/2fa/disable?123456", laptop))
+
+	// Enrol
+	b := req("/2fa", laptop)
+	is.True(strings.HasPrefix(b, "20 text/gemini\r\n# Two-factor authentication\n\nAdd this secret to your authenticator app:\n\n```\n"))
+	secret := b[strings.Index(b, "```\n")+4 : strings.LastIndex(b, "\n```")]
+	is.True(strings.Contains(b, "=> otpauth://totp/gig:jon?issuer=gig&secret="+secret+" Open in authenticator app\n=> /2fa/verify Enter code to confirm"))
+
+	code, err := TOTPCode(secret, time.Now())
+	is.NoErr(err)
+
+	is.Equal("10 Enter code from authenticator app\r\n", req("/2fa/verify", laptop))
+	is.Equal("10 Invalid code, try again\r\n", req("/2fa/verify?000000x", laptop))
+	is.Equal("10 Invalid code, try again\r\n", req("/2fa/verify?000000y", laptop))
+	is.Equal("30 /2fa\r\n", req("/2fa/verify?000000z", laptop))
+	is.Equal("30 /2fa\r\n", req("/2fa/verify?"+code, laptop))
+
+	// Enrol again
+	b = req("/2fa", laptop)
+	secret = b[strings.Index(b, "```\n")+4 : strings.LastIndex(b, "\n```")]
+
+	code, err = TOTPCode(secret, time.Now())
+	is.NoErr(err)
+
+	b = req("/2fa/verify?"+code, laptop)
+	is.True(strings.HasPrefix(b, "20 text/gemini\r\n# Two-factor authentication enabled\n\n"))
+
+	recovery := strings.Split(b[strings.Index(b, "```\n")+4:strings.LastIndex(b, "\n```")], "\n")
+	is.Equal(10, len(recovery))
+	is.Equal("20 text/gemini\r\n# Two-factor authentication\n\nTwo-factor authentication is enabled.\n\n=> /2fa/disable Disable", req("/2fa", laptop))
+
+	// Login asks for code
+	is.Equal("61 Invalid username or password\r\n", req("/login/jon?wrong", phone))
+	is.Equal("30 /login/jon/otp\r\n", req("/login/jon?winteriscoming", phone))
+	is.Equal("11 Enter code from authenticator app or a recovery code\r\n", req("/login/jon/otp", phone))
+	is.Equal("11 Invalid code, try again\r\n", req("/login/jon/otp?123", phone))
+	is.Equal("30 /private\r\n", req("/login/jon/otp?"+code, phone))
+
+	user, err := store.User(CertFingerprint(phone, FingerprintSHA256))
+	is.NoErr(err)
+	is.Equal("jon", user)
+	is.NoErr(store.Unpin(CertFingerprint(phone, FingerprintSHA256)))
+
+	// Codes can not be replayed, recovery codes can be used once
+	is.Equal("30 /login/jon/otp\r\n", req("/login/jon?winteriscoming", phone))
+	is.Equal("11 Invalid code, try again\r\n", req("/login/jon/otp?"+code, phone))
+	is.Equal("30 /private\r\n", req("/login/jon/otp?"+recovery[0], phone))
+	is.Equal("30 /login/jon/otp\r\n", req("/login/jon?winteriscoming", phone))
+	is.Equal("11 Invalid code, try again\r\n", req("/login/jon/otp?"+recovery[0], phone))
+
+	// Without pending password, start over
+	is.Equal("30 /login/jon\r\n", req("/login/jon/otp?"+recovery[1], laptop))
+
+	// Too many wrong codes, start over
+	is.Equal("30 /login/jon/otp\r\n", req("/login/jon?winteriscoming", phone))
+	is.Equal("11 Invalid code, try again\r\n", req("/login/jon/otp?000000", phone))
+	is.Equal("11 Invalid code, try again\r\n", req("/login/jon/otp?000001", phone))
+	is.Equal("30 /login/jon\r\n", req("/login/jon/otp?000002", phone))
+	is.Equal("30 /login/jon\r\n", req("/login/jon/otp?"+recovery[1], phone))
+
+	// Disable
+	is.Equal("10 Enter code from authenticator app\r\n", req("/2fa/disable", laptop))
+	is.Equal("10 Invalid code, try again\r\n", req("/2fa/disable?"+code, laptop))
+
+	next, err := TOTPCode(secret, time.Now().Add(30*time.Second))
+	is.NoErr(err)
+	is.Equal("20 text/gemini\r\n# Two-factor authentication disabled", req("/2fa/disable?"+next, laptop))
+	is.Equal("30 /2fa\r\n", req("/2fa/disable?"+next, laptop))
+	is.Equal("30 /private\r\n", req("/login/jon?winteriscoming", phone))
+}